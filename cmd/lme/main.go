@@ -12,8 +12,11 @@ import (
 	"github.com/SzymonLeja/local-memory-engine/internal/config"
 	"github.com/SzymonLeja/local-memory-engine/internal/db"
 	"github.com/SzymonLeja/local-memory-engine/internal/embeddings"
+	"github.com/SzymonLeja/local-memory-engine/internal/extract"
 	"github.com/SzymonLeja/local-memory-engine/internal/ingest"
 	"github.com/SzymonLeja/local-memory-engine/internal/jobs"
+	"github.com/SzymonLeja/local-memory-engine/internal/links"
+	"github.com/SzymonLeja/local-memory-engine/internal/memory"
 	lmemiddleware "github.com/SzymonLeja/local-memory-engine/internal/middleware"
 	"github.com/SzymonLeja/local-memory-engine/internal/provenance"
 	"github.com/SzymonLeja/local-memory-engine/internal/query"
@@ -39,13 +42,20 @@ func main() {
 	}
 	log.Println("Qdrant collection OK")
 
+	if cfg.MaxExtractSize > 0 {
+		extract.MaxDecompressedSize = cfg.MaxExtractSize
+	}
+
 	ollamaClient := embeddings.NewOllamaClient(cfg.OllamaURL, cfg.EmbeddingModel)
+	llmClient := embeddings.NewOllamaClient(cfg.OllamaURL, cfg.GenerateModel)
 
-	ingestSvc := ingest.NewService(dbConn, cfg, ollamaClient, qdrantClient)
-	querySvc := query.NewService(dbConn, cfg, ollamaClient, qdrantClient)
+	ingestSvc := ingest.NewService(dbConn, cfg, ollamaClient, llmClient, qdrantClient)
+	querySvc := query.NewService(dbConn, cfg, ollamaClient, llmClient, qdrantClient)
 
 	provenanceSvc := provenance.NewService(dbConn)
 	jobsSvc := jobs.NewService(dbConn)
+	linksSvc := links.NewService(dbConn)
+	memorySvc := memory.NewService(dbConn, cfg, ollamaClient, llmClient, qdrantClient)
 	r := chi.NewRouter()
 
 	r.Use(middleware.Logger)
@@ -53,7 +63,8 @@ func main() {
 	r.Use(cors.New(cors.Options{
 		AllowedOrigins: cfg.AllowedOrigins,
 		AllowedMethods: []string{"GET", "POST", "PATCH", "DELETE"},
-		AllowedHeaders: []string{"Content-Type", "X-API-Key"},
+		AllowedHeaders: []string{"Content-Type", "X-API-Key", "If-Match"},
+		ExposedHeaders: []string{"ETag"},
 	}).Handler)
 
 	r.Use(lmemiddleware.APIKeyAuth(cfg.ApiKey))
@@ -66,7 +77,30 @@ func main() {
 	r.Get("/provenance/{id}", provenanceSvc.GetHandler)
 	r.Get("/status/{job_id}", jobsSvc.GetHandler)
 	r.Get("/file/{filename}", ingestSvc.GetFileHandler)
+	r.Delete("/file/{filename}", ingestSvc.DeleteFileHandler)
+	r.Get("/file/{filename}/versions", ingestSvc.VersionsHandler)
+	r.Get("/file/{filename}/versions/{version}", ingestSvc.GetVersionHandler)
+	r.Get("/file/{filename}/diff", ingestSvc.DiffHandler)
+	r.Post("/file/{filename}/restore", ingestSvc.RestoreHandler)
+	r.Post("/file/move", ingestSvc.MoveFileHandler)
+	r.Get("/files", ingestSvc.ListFilesHandler)
+	r.Get("/tree", ingestSvc.TreeHandler)
 	r.Patch("/ingest", ingestSvc.PatchIngestHandler)
+	r.Post("/notes", ingestSvc.CreateNoteHandler)
+	r.Post("/notes/daily", ingestSvc.DailyNoteHandler)
+	r.Get("/files/{path}/links", linksSvc.LinksHandler)
+	r.Get("/files/{path}/backlinks", linksSvc.BacklinksHandler)
+	r.Get("/links/broken", linksSvc.BrokenHandler)
+	r.Get("/files/{path}/similar", querySvc.SimilarFileHandler)
+	r.Get("/chunks/{id}/similar", querySvc.SimilarChunkHandler)
+	r.Post("/memories", memorySvc.CreateHandler)
+	r.Get("/memories", memorySvc.ListHandler)
+	r.Post("/memories/consolidate", memorySvc.ConsolidateHandler)
+	r.Get("/memories/proposals", memorySvc.ProposalsHandler)
+	r.Post("/memories/proposals/{id}/apply", memorySvc.ApplyProposalHandler)
+	r.Post("/memories/proposals/{id}/reject", memorySvc.RejectProposalHandler)
+	r.Get("/memories/{id}", memorySvc.GetHandler)
+	r.Delete("/memories/{id}", memorySvc.DeleteHandler)
 
 	log.Printf("LME listening on %s", cfg.ListenAddr)
 
@@ -77,5 +111,11 @@ func main() {
 			log.Printf("watcher started on: %s", cfg.WatchPath)
 		}
 	}
+	if cfg.SessionTTL > 0 {
+		querySvc.StartSessionCleanup(context.Background())
+	}
+	if cfg.MemoryConsolidateInterval > 0 {
+		memorySvc.StartConsolidation(context.Background(), cfg.MemoryConsolidateInterval)
+	}
 	log.Fatal(http.ListenAndServe(cfg.ListenAddr, r))
 }
@@ -2,7 +2,9 @@ package config
 
 import (
 	"log"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -14,10 +16,28 @@ type Config struct {
 	QdrantCollection string
 	OllamaURL        string
 	EmbeddingModel   string
+	GenerateModel    string
 	AllowedOrigins   []string
 	ApiKey           string
 	VaultRoot        string
 	WatchPath        string
+	NotebookOutputs  bool
+	FrontMatterKeys  []string
+	MinScore         float64
+	ConfidenceMargin float64
+	QueryVariants    int
+	Summaries        bool
+	MaxVersions      int
+	MaxExtractSize   int64
+
+	SessionTTL time.Duration
+
+	RecencyHalfLifeDays float64
+	RecencyWeight       float64
+
+	MemoryMergeThreshold      float64
+	MemoryConsolidateInterval time.Duration
+	MemoryAutoApply           bool
 }
 
 func Load() *Config {
@@ -31,10 +51,25 @@ func Load() *Config {
 
 	viper.SetDefault("LISTEN_ADDR", ":8080")
 	viper.SetDefault("EMBEDDING_MODEL", "nomic-embed-text")
+	viper.SetDefault("GENERATE_MODEL", "llama3.2")
+	viper.SetDefault("QUERY_VARIANTS", 3)
+	viper.SetDefault("SUMMARIES", false)
+	viper.SetDefault("MAX_VERSIONS", 20)
+	viper.SetDefault("MAX_EXTRACT_SIZE", 256<<20)
+	viper.SetDefault("SESSION_TTL", "24h")
+	viper.SetDefault("RECENCY_HALF_LIFE_DAYS", 90)
+	viper.SetDefault("RECENCY_WEIGHT", 0.3)
+	viper.SetDefault("MEMORY_MERGE_THRESHOLD", 0.9)
+	viper.SetDefault("MEMORY_CONSOLIDATE_INTERVAL", "24h")
+	viper.SetDefault("MEMORY_AUTO_APPLY", false)
 	viper.SetDefault("ALLOWED_ORIGINS", "http://localhost")
 	viper.SetDefault("VAULT_ROOT", "./vault")
 	viper.SetDefault("QDRANT_COLLECTION", "lme")
 	viper.SetDefault("WATCH_PATH", ".")
+	viper.SetDefault("NOTEBOOK_OUTPUTS", true)
+	viper.SetDefault("FRONT_MATTER_KEYS", "tags,aliases,project,status,date")
+	viper.SetDefault("MIN_SCORES", "nomic-embed-text=0.35")
+	viper.SetDefault("CONFIDENCE_MARGIN", 0.05)
 
 	cfg := &Config{
 		ListenAddr:       viper.GetString("LISTEN_ADDR"),
@@ -43,11 +78,29 @@ func Load() *Config {
 		QdrantCollection: viper.GetString("QDRANT_COLLECTION"),
 		OllamaURL:        viper.GetString("OLLAMA_URL"),
 		EmbeddingModel:   viper.GetString("EMBEDDING_MODEL"),
+		GenerateModel:    viper.GetString("GENERATE_MODEL"),
 		AllowedOrigins:   strings.Split(viper.GetString("ALLOWED_ORIGINS"), ","),
 		ApiKey:           viper.GetString("API_KEY"),
 		VaultRoot:        viper.GetString("VAULT_ROOT"),
 		WatchPath:        viper.GetString("WATCH_PATH"),
+		NotebookOutputs:  viper.GetBool("NOTEBOOK_OUTPUTS"),
+		FrontMatterKeys:  splitCSV(viper.GetString("FRONT_MATTER_KEYS")),
+		ConfidenceMargin: viper.GetFloat64("CONFIDENCE_MARGIN"),
+		QueryVariants:    viper.GetInt("QUERY_VARIANTS"),
+		Summaries:        viper.GetBool("SUMMARIES"),
+		MaxVersions:      viper.GetInt("MAX_VERSIONS"),
+		MaxExtractSize:   viper.GetInt64("MAX_EXTRACT_SIZE"),
+
+		SessionTTL: viper.GetDuration("SESSION_TTL"),
+
+		RecencyHalfLifeDays: viper.GetFloat64("RECENCY_HALF_LIFE_DAYS"),
+		RecencyWeight:       viper.GetFloat64("RECENCY_WEIGHT"),
+
+		MemoryMergeThreshold:      viper.GetFloat64("MEMORY_MERGE_THRESHOLD"),
+		MemoryConsolidateInterval: viper.GetDuration("MEMORY_CONSOLIDATE_INTERVAL"),
+		MemoryAutoApply:           viper.GetBool("MEMORY_AUTO_APPLY"),
 	}
+	cfg.MinScore = modelScore(viper.GetString("MIN_SCORES"), cfg.EmbeddingModel)
 
 	if cfg.PostgresDSN == "" {
 		log.Fatal("POSTGRES_DSN is required")
@@ -58,6 +111,35 @@ func Load() *Config {
 	if cfg.OllamaURL == "" {
 		log.Fatal("OLLAMA_URL is required")
 	}
+	if cfg.RecencyHalfLifeDays <= 0 {
+		log.Fatal("RECENCY_HALF_LIFE_DAYS must be greater than 0")
+	}
 
 	return cfg
 }
+
+func splitCSV(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+func modelScore(s, model string) float64 {
+	for _, pair := range splitCSV(s) {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(name) != model {
+			continue
+		}
+		score, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			log.Printf("invalid MIN_SCORES entry %q: %v", pair, err)
+			continue
+		}
+		return score
+	}
+	return 0
+}
@@ -0,0 +1,250 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type Section struct {
+	Heading    string
+	Level      int
+	Paragraphs []string
+}
+
+// MaxDecompressedSize caps how much a single zip entry or compressed PDF
+// stream may expand to
+var MaxDecompressedSize int64 = 256 << 20
+
+var ErrTooLarge = errors.New("decompressed data exceeds size limit")
+
+func readLimited(r io.Reader) ([]byte, error) {
+	out, err := io.ReadAll(io.LimitReader(r, MaxDecompressedSize+1))
+	if int64(len(out)) > MaxDecompressedSize {
+		return nil, ErrTooLarge
+	}
+	return out, err
+}
+
+func openZip(data []byte) (*zip.Reader, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("open archive: %w", err)
+	}
+	return zr, nil
+}
+
+func readZipFile(zr *zip.Reader, name string) ([]byte, error) {
+	for _, f := range zr.File {
+		if f.Name != name {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return readLimited(rc)
+	}
+	return nil, fmt.Errorf("%s not found in archive", name)
+}
+
+var headingStyle = regexp.MustCompile(`(?i)^(?:heading\s*(\d)|title)$`)
+
+func DOCXSections(data []byte) ([]Section, error) {
+	zr, err := openZip(data)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := readZipFile(zr, "word/document.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	sections := []Section{{}}
+	var para strings.Builder
+	var style string
+	outline := -1
+	inText := false
+
+	dec := xml.NewDecoder(bytes.NewReader(doc))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse document.xml: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "p":
+				para.Reset()
+				style = ""
+				outline = -1
+			case "pStyle":
+				style = xmlAttr(t, "val")
+			case "outlineLvl":
+				if lvl, err := strconv.Atoi(xmlAttr(t, "val")); err == nil {
+					outline = lvl
+				}
+			case "t":
+				inText = true
+			case "tab":
+				para.WriteString("\t")
+			case "br", "cr":
+				para.WriteString("\n")
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				text := strings.TrimSpace(para.String())
+				if text == "" {
+					continue
+				}
+				if level := docxHeadingLevel(style, outline); level > 0 {
+					sections = append(sections, Section{Heading: text, Level: level})
+					continue
+				}
+				last := &sections[len(sections)-1]
+				last.Paragraphs = append(last.Paragraphs, text)
+			}
+		case xml.CharData:
+			if inText {
+				para.Write(t)
+			}
+		}
+	}
+
+	return compactSections(sections), nil
+}
+
+func docxHeadingLevel(style string, outline int) int {
+	if m := headingStyle.FindStringSubmatch(style); m != nil {
+		if m[1] == "" {
+			return 1
+		}
+		lvl, _ := strconv.Atoi(m[1])
+		return lvl
+	}
+	if outline >= 0 && outline < 9 {
+		return outline + 1
+	}
+	return 0
+}
+
+func ODTSections(data []byte) ([]Section, error) {
+	zr, err := openZip(data)
+	if err != nil {
+		return nil, err
+	}
+	content, err := readZipFile(zr, "content.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	sections := []Section{{}}
+	var para strings.Builder
+	depth := 0
+	heading := 0
+
+	dec := xml.NewDecoder(bytes.NewReader(content))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse content.xml: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "p", "h":
+				if depth == 0 {
+					para.Reset()
+					heading = 0
+					if t.Name.Local == "h" {
+						heading = 1
+						if lvl, err := strconv.Atoi(xmlAttr(t, "outline-level")); err == nil && lvl > 0 {
+							heading = lvl
+						}
+					}
+				}
+				depth++
+			case "s":
+				n := 1
+				if c, err := strconv.Atoi(xmlAttr(t, "c")); err == nil && c > 0 {
+					n = c
+				}
+				para.WriteString(strings.Repeat(" ", n))
+			case "tab":
+				para.WriteString("\t")
+			case "line-break":
+				para.WriteString("\n")
+			}
+		case xml.EndElement:
+			if t.Name.Local != "p" && t.Name.Local != "h" {
+				continue
+			}
+			depth--
+			if depth > 0 {
+				continue
+			}
+			text := strings.TrimSpace(para.String())
+			if text == "" {
+				continue
+			}
+			if heading > 0 {
+				sections = append(sections, Section{Heading: text, Level: heading})
+				continue
+			}
+			last := &sections[len(sections)-1]
+			last.Paragraphs = append(last.Paragraphs, text)
+		case xml.CharData:
+			if depth > 0 {
+				para.Write(t)
+			}
+		}
+	}
+
+	return compactSections(sections), nil
+}
+
+func compactSections(sections []Section) []Section {
+	if len(sections) > 0 && sections[0].Heading == "" && len(sections[0].Paragraphs) == 0 {
+		return sections[1:]
+	}
+	return sections
+}
+
+func SectionsText(sections []Section) string {
+	var parts []string
+	for _, sec := range sections {
+		if sec.Heading != "" {
+			parts = append(parts, strings.Repeat("#", min(max(sec.Level, 1), 6))+" "+sec.Heading)
+		}
+		parts = append(parts, sec.Paragraphs...)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+func xmlAttr(el xml.StartElement, local string) string {
+	for _, a := range el.Attr {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
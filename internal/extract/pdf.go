@@ -0,0 +1,1146 @@
+package extract
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/ascii85"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+var ErrEncryptedPDF = errors.New("encrypted PDF is not supported")
+
+const maxNesting = 256
+
+type pdfName string
+
+type pdfKeyword string
+
+type pdfString []byte
+
+type pdfRef struct {
+	Num int
+	Gen int
+}
+
+type pdfDict map[pdfName]any
+
+type pdfStream struct {
+	Dict pdfDict
+	Data []byte
+}
+
+type pdfDoc struct {
+	objects  map[int]any
+	trailers []pdfDict
+}
+
+func PDFPages(data []byte) ([]string, error) {
+	doc, err := parsePDF(data)
+	if err != nil {
+		return nil, err
+	}
+
+	pages, err := doc.pages()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]string, 0, len(pages))
+	for _, page := range pages {
+		out = append(out, doc.pageText(page))
+	}
+	return out, nil
+}
+
+var objHeader = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+
+func parsePDF(data []byte) (*pdfDoc, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n"), []byte("%PDF-")) {
+		return nil, fmt.Errorf("not a PDF file")
+	}
+
+	doc := &pdfDoc{objects: make(map[int]any)}
+
+	pos := 0
+	for pos < len(data) {
+		loc := objHeader.FindSubmatchIndex(data[pos:])
+		if loc == nil {
+			break
+		}
+		num, _ := strconv.Atoi(string(data[pos+loc[2] : pos+loc[3]]))
+		start := pos + loc[1]
+
+		l := &lexer{data: data, pos: start}
+		obj, err := l.readObject()
+		if err != nil {
+			pos = start
+			continue
+		}
+		if dict, ok := obj.(pdfDict); ok {
+			stream, err := l.readStream(dict)
+			if err != nil {
+				return nil, fmt.Errorf("object %d: %w", num, err)
+			}
+			if stream != nil {
+				obj = stream
+			}
+		}
+		doc.objects[num] = obj
+		pos = l.pos
+	}
+
+	for _, loc := range regexp.MustCompile(`trailer\s*<<`).FindAllIndex(data, -1) {
+		l := &lexer{data: data, pos: loc[1] - 2}
+		if obj, err := l.readObject(); err == nil {
+			if dict, ok := obj.(pdfDict); ok {
+				doc.trailers = append(doc.trailers, dict)
+			}
+		}
+	}
+
+	for _, obj := range doc.objects {
+		if stream, ok := obj.(*pdfStream); ok && stream.Dict["Type"] == pdfName("XRef") {
+			doc.trailers = append(doc.trailers, stream.Dict)
+		}
+	}
+	for _, t := range doc.trailers {
+		if _, ok := t["Encrypt"]; ok {
+			return nil, ErrEncryptedPDF
+		}
+	}
+
+	for num, obj := range doc.objects {
+		stream, ok := obj.(*pdfStream)
+		if !ok || stream.Dict["Type"] != pdfName("ObjStm") {
+			continue
+		}
+		if err := doc.loadObjectStream(stream); err != nil {
+			return nil, fmt.Errorf("object stream %d: %w", num, err)
+		}
+	}
+
+	return doc, nil
+}
+
+func (d *pdfDoc) loadObjectStream(stream *pdfStream) error {
+	data, err := d.decodeStream(stream)
+	if err != nil {
+		return err
+	}
+
+	n := toInt(d.resolve(stream.Dict["N"]))
+	first := toInt(d.resolve(stream.Dict["First"]))
+	if first < 0 || first > len(data) {
+		return fmt.Errorf("invalid /First offset %d", first)
+	}
+
+	header := &lexer{data: data[:first]}
+	for i := 0; i < n; i++ {
+		numTok, err := header.readObject()
+		if err != nil {
+			return err
+		}
+		offTok, err := header.readObject()
+		if err != nil {
+			return err
+		}
+		num, off := toInt(numTok), toInt(offTok)
+		if off < 0 || off > len(data)-first {
+			return fmt.Errorf("invalid offset %d for object %d", off, num)
+		}
+		if _, exists := d.objects[num]; exists {
+			continue
+		}
+		l := &lexer{data: data, pos: first + off}
+		obj, err := l.readObject()
+		if err != nil {
+			continue
+		}
+		d.objects[num] = obj
+	}
+	return nil
+}
+
+func (d *pdfDoc) resolve(v any) any {
+	for i := 0; i < 32; i++ {
+		ref, ok := v.(pdfRef)
+		if !ok {
+			return v
+		}
+		v = d.objects[ref.Num]
+	}
+	return nil
+}
+
+func (d *pdfDoc) dict(v any) pdfDict {
+	switch t := d.resolve(v).(type) {
+	case pdfDict:
+		return t
+	case *pdfStream:
+		return t.Dict
+	}
+	return nil
+}
+
+func (d *pdfDoc) catalog() pdfDict {
+	for _, t := range d.trailers {
+		if root := d.dict(t["Root"]); root != nil {
+			return root
+		}
+	}
+	for _, obj := range d.objects {
+		if dict, ok := obj.(pdfDict); ok && dict["Type"] == pdfName("Catalog") {
+			return dict
+		}
+	}
+	return nil
+}
+
+type pdfPage struct {
+	dict      pdfDict
+	resources pdfDict
+}
+
+func (d *pdfDoc) pages() ([]pdfPage, error) {
+	root := d.catalog()
+	if root == nil {
+		return nil, fmt.Errorf("PDF catalog not found")
+	}
+
+	var pages []pdfPage
+	visited := make(map[int]bool)
+
+	var walk func(node any, resources pdfDict)
+	walk = func(node any, resources pdfDict) {
+		if ref, ok := node.(pdfRef); ok {
+			if visited[ref.Num] {
+				return
+			}
+			visited[ref.Num] = true
+		}
+		dict := d.dict(node)
+		if dict == nil {
+			return
+		}
+		if res := d.dict(dict["Resources"]); res != nil {
+			resources = res
+		}
+		if kids, ok := d.resolve(dict["Kids"]).([]any); ok {
+			for _, kid := range kids {
+				walk(kid, resources)
+			}
+			return
+		}
+		if dict["Type"] == pdfName("Page") || dict["Contents"] != nil {
+			pages = append(pages, pdfPage{dict: dict, resources: resources})
+		}
+	}
+	walk(root["Pages"], nil)
+
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("PDF has no pages")
+	}
+	return pages, nil
+}
+
+func (d *pdfDoc) pageText(page pdfPage) string {
+	var content []byte
+	switch c := d.resolve(page.dict["Contents"]).(type) {
+	case *pdfStream:
+		content, _ = d.decodeStream(c)
+	case []any:
+		for _, part := range c {
+			if s, ok := d.resolve(part).(*pdfStream); ok {
+				data, err := d.decodeStream(s)
+				if err == nil {
+					content = append(content, data...)
+					content = append(content, '\n')
+				}
+			}
+		}
+	}
+
+	fonts := make(map[pdfName]*pdfFont)
+	if fontRes := d.dict(page.resources["Font"]); fontRes != nil {
+		for name, ref := range fontRes {
+			fonts[name] = d.loadFont(d.dict(ref))
+		}
+	}
+
+	return normalizePDFText(runContentStream(content, fonts))
+}
+
+func (d *pdfDoc) decodeStream(s *pdfStream) ([]byte, error) {
+	data := s.Data
+
+	var filters []any
+	switch f := d.resolve(s.Dict["Filter"]).(type) {
+	case pdfName:
+		filters = []any{f}
+	case []any:
+		filters = f
+	}
+	var params []any
+	switch p := d.resolve(s.Dict["DecodeParms"]).(type) {
+	case pdfDict:
+		params = []any{p}
+	case []any:
+		params = p
+	}
+
+	for i, f := range filters {
+		var parms pdfDict
+		if i < len(params) {
+			parms = d.dict(params[i])
+		}
+
+		var err error
+		switch d.resolve(f) {
+		case pdfName("FlateDecode"), pdfName("Fl"):
+			data, err = inflate(data)
+			if err == nil && parms != nil {
+				data, err = unpredict(data, parms)
+			}
+		case pdfName("ASCIIHexDecode"), pdfName("AHx"):
+			data, err = decodeASCIIHex(data)
+		case pdfName("ASCII85Decode"), pdfName("A85"):
+			data, err = decodeASCII85(data)
+		default:
+			return nil, fmt.Errorf("unsupported stream filter %v", f)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+func inflate(data []byte) ([]byte, error) {
+	r, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	out, err := readLimited(r)
+	if errors.Is(err, ErrTooLarge) || err != nil && len(out) == 0 {
+		return nil, err
+	}
+	return out, nil
+}
+
+func unpredict(data []byte, parms pdfDict) ([]byte, error) {
+	predictor := toInt(parms["Predictor"])
+	if predictor < 10 {
+		return data, nil
+	}
+
+	columns := toInt(parms["Columns"])
+	if columns == 0 {
+		columns = 1
+	}
+	if columns < 0 || columns > 8*len(data) {
+		return nil, fmt.Errorf("invalid predictor /Columns %d", columns)
+	}
+	colors := toInt(parms["Colors"])
+	if colors == 0 {
+		colors = 1
+	}
+	if colors < 0 || colors > 32 {
+		return nil, fmt.Errorf("invalid predictor /Colors %d", colors)
+	}
+	bpc := toInt(parms["BitsPerComponent"])
+	switch bpc {
+	case 0:
+		bpc = 8
+	case 1, 2, 4, 8, 16:
+	default:
+		return nil, fmt.Errorf("invalid predictor /BitsPerComponent %d", bpc)
+	}
+	bpp := (colors*bpc + 7) / 8
+	rowLen := (columns*colors*bpc + 7) / 8
+
+	var out []byte
+	prev := make([]byte, rowLen)
+	for len(data) >= rowLen+1 {
+		filter, row := data[0], append([]byte(nil), data[1:rowLen+1]...)
+		data = data[rowLen+1:]
+
+		for i := range row {
+			var left, up, upLeft byte
+			if i >= bpp {
+				left = row[i-bpp]
+				upLeft = prev[i-bpp]
+			}
+			up = prev[i]
+			switch filter {
+			case 1:
+				row[i] += left
+			case 2:
+				row[i] += up
+			case 3:
+				row[i] += byte((int(left) + int(up)) / 2)
+			case 4:
+				row[i] += paeth(left, up, upLeft)
+			}
+		}
+		out = append(out, row...)
+		prev = row
+	}
+	return out, nil
+}
+
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	if pa <= pb && pa <= pc {
+		return a
+	}
+	if pb <= pc {
+		return b
+	}
+	return c
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func decodeASCIIHex(data []byte) ([]byte, error) {
+	var clean []byte
+	for _, c := range data {
+		if c == '>' {
+			break
+		}
+		if isHexDigit(c) {
+			clean = append(clean, c)
+		}
+	}
+	if len(clean)%2 == 1 {
+		clean = append(clean, '0')
+	}
+	out := make([]byte, len(clean)/2)
+	_, err := hex.Decode(out, clean)
+	return out, err
+}
+
+func decodeASCII85(data []byte) ([]byte, error) {
+	data = bytes.TrimPrefix(bytes.TrimSpace(data), []byte("<~"))
+	if i := bytes.Index(data, []byte("~>")); i >= 0 {
+		data = data[:i]
+	}
+	out := make([]byte, len(data))
+	n, _, err := ascii85.Decode(out, data, true)
+	return out[:n], err
+}
+
+func toInt(v any) int {
+	if f, ok := v.(float64); ok && f >= math.MinInt32 && f <= math.MaxInt32 {
+		return int(f)
+	}
+	return 0
+}
+
+func toFloat(v any) float64 {
+	if f, ok := v.(float64); ok {
+		return f
+	}
+	return 0
+}
+
+type pdfFont struct {
+	twoByte  bool
+	toUni    map[uint32]string
+	widths   []int
+	encoding map[byte]string
+}
+
+func (d *pdfDoc) loadFont(dict pdfDict) *pdfFont {
+	font := &pdfFont{}
+	if dict == nil {
+		return font
+	}
+
+	font.twoByte = dict["Subtype"] == pdfName("Type0")
+
+	if s, ok := d.resolve(dict["ToUnicode"]).(*pdfStream); ok {
+		if data, err := d.decodeStream(s); err == nil {
+			font.toUni, font.widths = parseCMap(data)
+		}
+	}
+
+	if enc := d.dict(dict["Encoding"]); enc != nil {
+		if diffs, ok := d.resolve(enc["Differences"]).([]any); ok {
+			font.encoding = make(map[byte]string)
+			code := 0
+			for _, item := range diffs {
+				switch t := item.(type) {
+				case float64:
+					code = int(t)
+				case pdfName:
+					if r, ok := glyphToRune(string(t)); ok && code < 256 {
+						font.encoding[byte(code)] = r
+					}
+					code++
+				}
+			}
+		}
+	}
+	return font
+}
+
+func (f *pdfFont) decode(s []byte) string {
+	if f == nil {
+		return latin1(s)
+	}
+
+	if f.toUni != nil {
+		widths := f.widths
+		if len(widths) == 0 {
+			widths = []int{1}
+			if f.twoByte {
+				widths = []int{2}
+			}
+		}
+		var b strings.Builder
+		for i := 0; i < len(s); {
+			matched := false
+			for _, w := range widths {
+				if i+w > len(s) {
+					continue
+				}
+				var code uint32
+				for _, c := range s[i : i+w] {
+					code = code<<8 | uint32(c)
+				}
+				if u, ok := f.toUni[code]; ok {
+					b.WriteString(u)
+					i += w
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				i += widths[0]
+			}
+		}
+		return b.String()
+	}
+
+	if f.twoByte {
+		return ""
+	}
+
+	if f.encoding != nil {
+		var b strings.Builder
+		for _, c := range s {
+			if u, ok := f.encoding[c]; ok {
+				b.WriteString(u)
+			} else {
+				b.WriteString(latin1([]byte{c}))
+			}
+		}
+		return b.String()
+	}
+	return latin1(s)
+}
+
+func parseCMap(data []byte) (map[uint32]string, []int) {
+	toUni := make(map[uint32]string)
+	widthSet := make(map[int]bool)
+
+	l := &lexer{data: data}
+	var operands []any
+	mode := ""
+	for {
+		obj, err := l.readObject()
+		if err != nil {
+			break
+		}
+		kw, ok := obj.(pdfKeyword)
+		if !ok {
+			if mode != "" {
+				operands = append(operands, obj)
+			}
+			continue
+		}
+
+		switch kw {
+		case "begincodespacerange", "beginbfchar", "beginbfrange":
+			mode = string(kw)
+			operands = nil
+		case "endcodespacerange":
+			for i := 0; i+1 < len(operands); i += 2 {
+				if lo, ok := operands[i].(pdfString); ok {
+					widthSet[len(lo)] = true
+				}
+			}
+			mode = ""
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				src, ok1 := operands[i].(pdfString)
+				dst, ok2 := operands[i+1].(pdfString)
+				if ok1 && ok2 {
+					toUni[bytesToCode(src)] = utf16BE(dst)
+				}
+			}
+			mode = ""
+		case "endbfrange":
+			for i := 0; i+2 < len(operands); i += 3 {
+				lo, ok1 := operands[i].(pdfString)
+				hi, ok2 := operands[i+1].(pdfString)
+				if !ok1 || !ok2 {
+					continue
+				}
+				loCode, hiCode := bytesToCode(lo), bytesToCode(hi)
+				if hiCode < loCode || hiCode-loCode > 0xFFFF {
+					continue
+				}
+				switch dst := operands[i+2].(type) {
+				case pdfString:
+					base := []rune(utf16BE(dst))
+					if len(base) == 0 {
+						continue
+					}
+					for code := loCode; code <= hiCode; code++ {
+						r := append([]rune(nil), base...)
+						r[len(r)-1] += rune(code - loCode)
+						toUni[code] = string(r)
+					}
+				case []any:
+					for j, item := range dst {
+						if s, ok := item.(pdfString); ok && loCode+uint32(j) <= hiCode {
+							toUni[loCode+uint32(j)] = utf16BE(s)
+						}
+					}
+				}
+			}
+			mode = ""
+		}
+	}
+
+	var widths []int
+	for w := 1; w <= 4; w++ {
+		if widthSet[w] {
+			widths = append(widths, w)
+		}
+	}
+	return toUni, widths
+}
+
+func bytesToCode(b []byte) uint32 {
+	var code uint32
+	for _, c := range b {
+		code = code<<8 | uint32(c)
+	}
+	return code
+}
+
+func utf16BE(b []byte) string {
+	if len(b)%2 == 1 {
+		b = append(b, 0)
+	}
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		u[i] = uint16(b[2*i])<<8 | uint16(b[2*i+1])
+	}
+	return string(utf16.Decode(u))
+}
+
+var cp1252 = map[byte]rune{
+	0x80: '€', 0x82: '‚', 0x84: '„', 0x85: '…', 0x8B: '‹', 0x91: '‘', 0x92: '’',
+	0x93: '“', 0x94: '”', 0x95: '•', 0x96: '–', 0x97: '—', 0x99: '™', 0x9B: '›',
+}
+
+func latin1(s []byte) string {
+	var b strings.Builder
+	for _, c := range s {
+		if r, ok := cp1252[c]; ok {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune(rune(c))
+		}
+	}
+	return b.String()
+}
+
+var glyphNames = map[string]string{
+	"space": " ", "quoteright": "’", "quoteleft": "‘", "quotedblleft": "“", "quotedblright": "”",
+	"endash": "–", "emdash": "—", "bullet": "•", "ellipsis": "…", "fi": "fi", "fl": "fl", "ff": "ff",
+	"ffi": "ffi", "ffl": "ffl", "hyphen": "-", "period": ".", "comma": ",", "colon": ":",
+	"semicolon": ";", "parenleft": "(", "parenright": ")", "quotesingle": "'", "quotedbl": "\"",
+	"zero": "0", "one": "1", "two": "2", "three": "3", "four": "4", "five": "5", "six": "6",
+	"seven": "7", "eight": "8", "nine": "9",
+}
+
+func glyphToRune(name string) (string, bool) {
+	if s, ok := glyphNames[name]; ok {
+		return s, true
+	}
+	if len(name) == 1 {
+		return name, true
+	}
+	if strings.HasPrefix(name, "uni") && len(name) == 7 {
+		if code, err := strconv.ParseUint(name[3:], 16, 32); err == nil {
+			return string(rune(code)), true
+		}
+	}
+	return "", false
+}
+
+func runContentStream(content []byte, fonts map[pdfName]*pdfFont) string {
+	var b strings.Builder
+	var font *pdfFont
+	var fontSize, leading float64 = 1, 0
+	var lineY, scale float64 = 0, 1
+	var lastY float64
+	started := false
+
+	newLine := func(y float64) {
+		if !started {
+			lastY = y
+			return
+		}
+		height := math.Abs(fontSize * scale)
+		if height == 0 {
+			height = 1
+		}
+		gap := math.Abs(lastY - y)
+		switch {
+		case gap > 1.8*height:
+			b.WriteString("\n\n")
+		case gap > 0.1*height:
+			b.WriteString("\n")
+		default:
+			b.WriteString(" ")
+		}
+		lastY = y
+	}
+	show := func(s pdfString) {
+		text := font.decode(s)
+		if text == "" {
+			return
+		}
+		started = true
+		b.WriteString(text)
+	}
+
+	l := &lexer{data: content}
+	var operands []any
+	for {
+		obj, err := l.readObject()
+		if err != nil {
+			break
+		}
+		op, ok := obj.(pdfKeyword)
+		if !ok {
+			operands = append(operands, obj)
+			continue
+		}
+
+		switch op {
+		case "BT":
+			lineY, scale = 0, 1
+		case "Tf":
+			if len(operands) >= 2 {
+				if name, ok := operands[0].(pdfName); ok {
+					font = fonts[name]
+				}
+				fontSize = toFloat(operands[1])
+			}
+		case "TL":
+			if len(operands) >= 1 {
+				leading = toFloat(operands[0])
+			}
+		case "Td", "TD":
+			if len(operands) >= 2 {
+				ty := toFloat(operands[1])
+				if op == "TD" {
+					leading = -ty
+				}
+				if ty != 0 {
+					lineY += ty * scale
+					newLine(lineY)
+				} else if started {
+					b.WriteString(" ")
+				}
+			}
+		case "Tm":
+			if len(operands) >= 6 {
+				if d := toFloat(operands[3]); d != 0 {
+					scale = math.Abs(d)
+				}
+				lineY = toFloat(operands[5])
+				newLine(lineY)
+			}
+		case "T*":
+			lineY -= leading * scale
+			newLine(lineY)
+		case "Tj":
+			if len(operands) >= 1 {
+				if s, ok := operands[len(operands)-1].(pdfString); ok {
+					show(s)
+				}
+			}
+		case "'", "\"":
+			lineY -= leading * scale
+			newLine(lineY)
+			if len(operands) >= 1 {
+				if s, ok := operands[len(operands)-1].(pdfString); ok {
+					show(s)
+				}
+			}
+		case "TJ":
+			if len(operands) >= 1 {
+				if arr, ok := operands[len(operands)-1].([]any); ok {
+					for _, item := range arr {
+						switch t := item.(type) {
+						case pdfString:
+							show(t)
+						case float64:
+							if t < -150 && started {
+								b.WriteString(" ")
+							}
+						}
+					}
+				}
+			}
+		case "ID":
+			l.skipInlineImage()
+		case "ET":
+			if started {
+				b.WriteString(" ")
+			}
+		}
+		operands = operands[:0]
+	}
+
+	return b.String()
+}
+
+var (
+	multiSpace   = regexp.MustCompile(`[ \t\x00]+`)
+	multiNewline = regexp.MustCompile(`\n{3,}`)
+)
+
+func normalizePDFText(s string) string {
+	s = multiSpace.ReplaceAllString(s, " ")
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	s = strings.Join(lines, "\n")
+	s = multiNewline.ReplaceAllString(s, "\n\n")
+	return strings.TrimSpace(s)
+}
+
+type lexer struct {
+	data  []byte
+	pos   int
+	depth int
+}
+
+var (
+	errEOF         = errors.New("unexpected end of data")
+	errNesting     = errors.New("objects nested too deeply")
+	errInvalidSize = errors.New("invalid stream /Length")
+)
+
+func isWhitespace(c byte) bool {
+	return c == 0 || c == '\t' || c == '\n' || c == '\f' || c == '\r' || c == ' '
+}
+
+func isDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func (l *lexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if isWhitespace(c) {
+			l.pos++
+			continue
+		}
+		if c == '%' {
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+			continue
+		}
+		break
+	}
+}
+
+func (l *lexer) token() (any, error) {
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil, errEOF
+	}
+
+	c := l.data[l.pos]
+	switch {
+	case c == '/':
+		l.pos++
+		start := l.pos
+		for l.pos < len(l.data) && !isWhitespace(l.data[l.pos]) && !isDelimiter(l.data[l.pos]) {
+			l.pos++
+		}
+		return pdfName(decodeName(l.data[start:l.pos])), nil
+	case c == '(':
+		return l.literalString(), nil
+	case c == '<':
+		if l.pos+1 < len(l.data) && l.data[l.pos+1] == '<' {
+			l.pos += 2
+			return pdfKeyword("<<"), nil
+		}
+		l.pos++
+		start := l.pos
+		for l.pos < len(l.data) && l.data[l.pos] != '>' {
+			l.pos++
+		}
+		raw := l.data[start:l.pos]
+		l.pos++
+		s, _ := decodeASCIIHex(raw)
+		return pdfString(s), nil
+	case c == '>':
+		if l.pos+1 < len(l.data) && l.data[l.pos+1] == '>' {
+			l.pos += 2
+			return pdfKeyword(">>"), nil
+		}
+		l.pos++
+		return pdfKeyword(">"), nil
+	case c == '[' || c == ']' || c == '{' || c == '}':
+		l.pos++
+		return pdfKeyword(string(c)), nil
+	case c == ')':
+		l.pos++
+		return pdfKeyword(")"), nil
+	}
+
+	start := l.pos
+	for l.pos < len(l.data) && !isWhitespace(l.data[l.pos]) && !isDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	word := string(l.data[start:l.pos])
+	if f, err := strconv.ParseFloat(word, 64); err == nil && strings.IndexAny(word[:1], "+-.0123456789") == 0 {
+		return f, nil
+	}
+	return pdfKeyword(word), nil
+}
+
+func (l *lexer) readObject() (any, error) {
+	tok, err := l.token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := tok.(type) {
+	case pdfKeyword:
+		if t == "<<" || t == "[" {
+			if l.depth >= maxNesting {
+				return nil, errNesting
+			}
+			l.depth++
+			defer func() { l.depth-- }()
+		}
+		switch t {
+		case "<<":
+			dict := make(pdfDict)
+			for {
+				key, err := l.readObject()
+				if err != nil {
+					return nil, err
+				}
+				if key == pdfKeyword(">>") {
+					return dict, nil
+				}
+				name, ok := key.(pdfName)
+				if !ok {
+					continue
+				}
+				val, err := l.readObject()
+				if err != nil {
+					return nil, err
+				}
+				if val == pdfKeyword(">>") {
+					return dict, nil
+				}
+				dict[name] = val
+			}
+		case "[":
+			var arr []any
+			for {
+				item, err := l.readObject()
+				if err != nil {
+					return nil, err
+				}
+				if item == pdfKeyword("]") {
+					if arr == nil {
+						arr = []any{}
+					}
+					return arr, nil
+				}
+				arr = append(arr, item)
+			}
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		return t, nil
+	case float64:
+		save := l.pos
+		if gen, err := l.token(); err == nil {
+			if g, ok := gen.(float64); ok {
+				if r, err := l.token(); err == nil && r == pdfKeyword("R") {
+					return pdfRef{Num: int(t), Gen: int(g)}, nil
+				}
+			}
+		}
+		l.pos = save
+		return t, nil
+	}
+	return tok, nil
+}
+
+func (l *lexer) readStream(dict pdfDict) (*pdfStream, error) {
+	save := l.pos
+	tok, err := l.token()
+	if err != nil || tok != pdfKeyword("stream") {
+		l.pos = save
+		return nil, nil
+	}
+
+	if l.pos < len(l.data) && l.data[l.pos] == '\r' {
+		l.pos++
+	}
+	if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+		l.pos++
+	}
+	start := l.pos
+
+	if length, ok := dict["Length"].(float64); ok {
+		if length < 0 || length > float64(len(l.data)-start) {
+			return nil, fmt.Errorf("%w: %v", errInvalidSize, length)
+		}
+		end := start + int(length)
+		rest := bytes.TrimLeft(l.data[end:], " \t\r\n")
+		if bytes.HasPrefix(rest, []byte("endstream")) {
+			l.pos = end
+			l.token()
+			return &pdfStream{Dict: dict, Data: l.data[start:end]}, nil
+		}
+	}
+
+	idx := bytes.Index(l.data[start:], []byte("endstream"))
+	if idx < 0 {
+		l.pos = len(l.data)
+		return &pdfStream{Dict: dict, Data: l.data[start:]}, nil
+	}
+	end := start + idx
+	l.pos = end + len("endstream")
+	return &pdfStream{Dict: dict, Data: bytes.TrimRight(l.data[start:end], "\r\n")}, nil
+}
+
+func (l *lexer) skipInlineImage() {
+	for l.pos+2 < len(l.data) {
+		if isWhitespace(l.data[l.pos]) && l.data[l.pos+1] == 'E' && l.data[l.pos+2] == 'I' &&
+			(l.pos+3 == len(l.data) || isWhitespace(l.data[l.pos+3])) {
+			l.pos += 3
+			return
+		}
+		l.pos++
+	}
+	l.pos = len(l.data)
+}
+
+func (l *lexer) literalString() pdfString {
+	l.pos++
+	var out []byte
+	depth := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+			out = append(out, c)
+		case ')':
+			depth--
+			if depth == 0 {
+				return out
+			}
+			out = append(out, c)
+		case '\\':
+			if l.pos >= len(l.data) {
+				return out
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b':
+				out = append(out, '\b')
+			case 'f':
+				out = append(out, '\f')
+			case '\r':
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+			case '\n':
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						v = v*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					out = append(out, byte(v))
+				} else {
+					out = append(out, e)
+				}
+			}
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func decodeName(raw []byte) string {
+	if bytes.IndexByte(raw, '#') < 0 {
+		return string(raw)
+	}
+	var out []byte
+	for i := 0; i < len(raw); i++ {
+		if raw[i] == '#' && i+2 < len(raw) && isHexDigit(raw[i+1]) && isHexDigit(raw[i+2]) {
+			v, _ := strconv.ParseUint(string(raw[i+1:i+3]), 16, 8)
+			out = append(out, byte(v))
+			i += 2
+			continue
+		}
+		out = append(out, raw[i])
+	}
+	return string(out)
+}
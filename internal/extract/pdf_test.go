@@ -0,0 +1,155 @@
+package extract
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func buildPDF(objects ...string) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.7\n")
+	for i, obj := range objects {
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	b.WriteString("trailer\n<< /Root 1 0 R >>\n%%EOF\n")
+	return b.Bytes()
+}
+
+func stream(dict, data string) string {
+	return fmt.Sprintf("<< %s /Length %d >>\nstream\n%s\nendstream", dict, len(data), data)
+}
+
+func deflate(data []byte) string {
+	var b bytes.Buffer
+	w := zlib.NewWriter(&b)
+	w.Write(data)
+	w.Close()
+	return b.String()
+}
+
+const pageContent = "BT /F1 12 Tf 72 720 Td (Hello PDF) Tj ET"
+
+func simplePDF() []byte {
+	return buildPDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+		stream("", pageContent),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+	)
+}
+
+func objectStreamPDF(dict, data string) []byte {
+	return buildPDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [5 0 R] /Count 1 >>",
+		stream("", pageContent),
+		stream("/Type /ObjStm /N 1 "+dict, data),
+	)
+}
+
+const packedPage = "5 0 << /Type /Page /Contents 3 0 R >>"
+
+func offsetsPDF(first, offset string) []byte {
+	return objectStreamPDF("/First "+first, "5 "+offset+" "+packedPage[4:])
+}
+
+// predictedPDF packs the page into an object stream compressed with PNG
+// row predictors, eight bytes per row.
+func predictedPDF(parms string) []byte {
+	var rows []byte
+	data := []byte(fmt.Sprintf("%-48s", "5 0 "+packedPage[4:]))
+	for len(data) > 0 {
+		rows = append(rows, 0)
+		rows = append(rows, data[:8]...)
+		data = data[8:]
+	}
+	return objectStreamPDF("/First 4 /Filter /FlateDecode /DecodeParms << /Predictor 12 "+parms+" >>", deflate(rows))
+}
+
+func TestPDFPages(t *testing.T) {
+	pages, err := PDFPages(simplePDF())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) != 1 || pages[0] != "Hello PDF" {
+		t.Fatalf("got %q", pages)
+	}
+
+	for name, data := range map[string][]byte{
+		"object stream": offsetsPDF("4", "0"),
+		"predictor":     predictedPDF("/Columns 8"),
+	} {
+		pages, err := PDFPages(data)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(pages) != 1 || pages[0] != "Hello PDF" {
+			t.Fatalf("%s: got %q", name, pages)
+		}
+	}
+}
+
+func TestPDFPagesMalformed(t *testing.T) {
+	cases := map[string][]byte{
+		"negative length": buildPDF(
+			"<< /Type /Catalog /Pages 2 0 R >>",
+			"<< /Length -100 >>\nstream\n"+pageContent+"\nendstream",
+		),
+		"length past end": buildPDF(
+			"<< /Type /Catalog /Pages 2 0 R >>",
+			"<< /Length 100000 >>\nstream\n"+pageContent+"\nendstream",
+		),
+		"negative columns": predictedPDF("/Columns -50"),
+		"negative colors":  predictedPDF("/Columns 8 /Colors -3"),
+		"invalid bits":     predictedPDF("/Columns 8 /BitsPerComponent 7"),
+		"negative first":   offsetsPDF("-5", "0"),
+		"first past end":   offsetsPDF("5000", "0"),
+		"negative offset":  offsetsPDF("4", "-40"),
+		"offset past end":  offsetsPDF("4", "9000"),
+		"deeply nested":    buildPDF(strings.Repeat("[", 100000)),
+		"encrypted":        []byte("%PDF-1.7\ntrailer\n<< /Encrypt 1 0 R >>\n"),
+		"not a pdf":        []byte("hello"),
+	}
+	for name, data := range cases {
+		t.Run(name, func(t *testing.T) {
+			if pages, err := PDFPages(data); err == nil {
+				t.Fatalf("expected an error, got %q", pages)
+			}
+		})
+	}
+}
+
+func TestPDFPagesDecompressionLimit(t *testing.T) {
+	defer func(size int64) { MaxDecompressedSize = size }(MaxDecompressedSize)
+	MaxDecompressedSize = 1 << 10
+
+	packed := fmt.Sprintf("%-1048576s", "5 0 "+packedPage[4:])
+	data := objectStreamPDF("/First 4 /Filter /FlateDecode", deflate([]byte(packed)))
+	if _, err := PDFPages(data); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("expected ErrTooLarge, got %v", err)
+	}
+}
+
+func FuzzPDFPages(f *testing.F) {
+	f.Add(simplePDF())
+	f.Add(predictedPDF("/Columns 8"))
+	f.Add(offsetsPDF("4", "0"))
+	f.Add(buildPDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] >>",
+		"<< /Type /Page /Contents [4 0 R 5 0 R] /Resources << /Font << /F1 6 0 R >> >> >>",
+		stream("/Filter /ASCIIHexDecode", "4254202f463120313220546620283c3e29205469204554>"),
+		stream("/Filter [/ASCII85Decode]", "<~6<$bhD.RU,~>"),
+		"<< /Type /Font /Subtype /Type0 /ToUnicode 7 0 R >>",
+		stream("", "1 begincodespacerange <0000> <FFFF> endcodespacerange 1 beginbfrange <0001> <0003> <0041> endbfrange"),
+	))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		PDFPages(data)
+	})
+}
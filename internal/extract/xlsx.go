@@ -0,0 +1,261 @@
+package extract
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// sheet size limits of Excel itself; the last column is XFD
+const (
+	maxSheetRows    = 1048576
+	maxSheetColumns = 16384
+)
+
+type Sheet struct {
+	Name string
+	Rows [][]string
+}
+
+func EmptyRow(row []string) bool {
+	for _, c := range row {
+		if c != "" {
+			return false
+		}
+	}
+	return true
+}
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRels struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+func XLSXSheets(data []byte) ([]Sheet, error) {
+	zr, err := openZip(data)
+	if err != nil {
+		return nil, err
+	}
+
+	wbData, err := readZipFile(zr, "xl/workbook.xml")
+	if err != nil {
+		return nil, err
+	}
+	var wb xlsxWorkbook
+	if err := xml.Unmarshal(wbData, &wb); err != nil {
+		return nil, fmt.Errorf("parse workbook.xml: %w", err)
+	}
+
+	targets := make(map[string]string)
+	if relsData, err := readZipFile(zr, "xl/_rels/workbook.xml.rels"); err == nil {
+		var rels xlsxRels
+		if err := xml.Unmarshal(relsData, &rels); err == nil {
+			for _, r := range rels.Relationships {
+				target := strings.TrimPrefix(r.Target, "/")
+				if !strings.HasPrefix(target, "xl/") {
+					target = path.Join("xl", target)
+				}
+				targets[r.ID] = target
+			}
+		}
+	}
+
+	var shared []string
+	if ssData, err := readZipFile(zr, "xl/sharedStrings.xml"); err == nil {
+		shared, err = parseSharedStrings(ssData)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var sheets []Sheet
+	for i, s := range wb.Sheets {
+		target, ok := targets[s.RID]
+		if !ok {
+			target = fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1)
+		}
+		sheetData, err := readZipFile(zr, target)
+		if err != nil {
+			return nil, err
+		}
+		rows, err := parseSheetRows(sheetData, shared)
+		if err != nil {
+			return nil, fmt.Errorf("parse sheet %s: %w", s.Name, err)
+		}
+		sheets = append(sheets, Sheet{Name: s.Name, Rows: rows})
+	}
+	return sheets, nil
+}
+
+func parseSharedStrings(data []byte) ([]string, error) {
+	var out []string
+	var cur strings.Builder
+	inText, inPhonetic := false, false
+
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse sharedStrings.xml: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "si":
+				cur.Reset()
+			case "t":
+				inText = true
+			case "rPh":
+				inPhonetic = true
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "si":
+				out = append(out, cur.String())
+			case "t":
+				inText = false
+			case "rPh":
+				inPhonetic = false
+			}
+		case xml.CharData:
+			if inText && !inPhonetic {
+				cur.Write(t)
+			}
+		}
+	}
+	return out, nil
+}
+
+func parseSheetRows(data []byte, shared []string) ([][]string, error) {
+	var rows [][]string
+	var row []string
+	var cellType, cellRef string
+	var value strings.Builder
+	inValue := false
+
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "row":
+				row = nil
+				if n, err := strconv.Atoi(xmlAttr(t, "r")); err == nil {
+					if n > maxSheetRows {
+						return nil, fmt.Errorf("row %d exceeds %d rows", n, maxSheetRows)
+					}
+					for len(rows) < n-1 {
+						rows = append(rows, nil)
+					}
+				}
+			case "c":
+				cellType = xmlAttr(t, "t")
+				cellRef = xmlAttr(t, "r")
+				value.Reset()
+			case "v", "t":
+				inValue = true
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "v", "t":
+				inValue = false
+			case "c":
+				text := value.String()
+				switch cellType {
+				case "s":
+					if idx, err := strconv.Atoi(text); err == nil && idx >= 0 && idx < len(shared) {
+						text = shared[idx]
+					}
+				case "b":
+					text = map[string]string{"0": "FALSE", "1": "TRUE"}[text]
+				}
+				col, ok := columnIndex(cellRef)
+				if !ok {
+					continue
+				}
+				if col < 0 {
+					col = len(row)
+				}
+				if col >= maxSheetColumns {
+					continue
+				}
+				for len(row) <= col {
+					row = append(row, "")
+				}
+				row[col] = strings.TrimSpace(text)
+			case "row":
+				if EmptyRow(row) {
+					row = nil
+				}
+				rows = append(rows, row)
+			}
+		case xml.CharData:
+			if inValue {
+				value.Write(t)
+			}
+		}
+	}
+	return rows, nil
+}
+
+// columnIndex returns the zero-based column of a cell reference like "B7",
+// -1 if the reference has no column letters and false if the column is past
+// the last one a sheet can have
+func columnIndex(ref string) (int, bool) {
+	col := 0
+	n := 0
+	for _, c := range ref {
+		if c < 'A' || c > 'Z' {
+			break
+		}
+		if n == 3 {
+			return 0, false
+		}
+		col = col*26 + int(c-'A'+1)
+		n++
+	}
+	if n == 0 {
+		return -1, true
+	}
+	if col > maxSheetColumns {
+		return 0, false
+	}
+	return col - 1, true
+}
+
+func SheetsText(sheets []Sheet) string {
+	var parts []string
+	for _, s := range sheets {
+		lines := []string{"## " + s.Name}
+		for _, row := range s.Rows {
+			if !EmptyRow(row) {
+				lines = append(lines, strings.Join(row, " | "))
+			}
+		}
+		parts = append(parts, strings.Join(lines, "\n"))
+	}
+	return strings.Join(parts, "\n\n")
+}
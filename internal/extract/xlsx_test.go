@@ -0,0 +1,54 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+)
+
+func buildXLSX(sheet string) []byte {
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+	for name, content := range map[string]string{
+		"xl/workbook.xml":          `<workbook><sheets><sheet name="Data"/></sheets></workbook>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData>` + sheet + `</sheetData></worksheet>`,
+	} {
+		w, _ := zw.Create(name)
+		w.Write([]byte(content))
+	}
+	zw.Close()
+	return b.Bytes()
+}
+
+func TestXLSXSheets(t *testing.T) {
+	sheets, err := XLSXSheets(buildXLSX(
+		`<row r="1"><c r="A1" t="inlineStr"><is><t>name</t></is></c><c r="C1"><v>3</v></c></row>` +
+			`<row r="3"><c r="B3"><v>7</v></c><c r="ZZZZZZZZ3"><v>1</v></c><c r="XFE3"><v>2</v></c></row>`,
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := sheets[0].Rows
+	if len(rows) != 3 || len(rows[0]) != 3 || rows[0][2] != "3" || len(rows[2]) != 2 || rows[2][1] != "7" {
+		t.Fatalf("got %q", rows)
+	}
+}
+
+func TestXLSXSheetsRowLimit(t *testing.T) {
+	if _, err := XLSXSheets(buildXLSX(`<row r="2000000000"><c r="A2000000000"><v>1</v></c></row>`)); err == nil {
+		t.Fatal("expected an error for a row past the sheet limit")
+	}
+}
+
+func TestColumnIndex(t *testing.T) {
+	for ref, want := range map[string]int{"A1": 0, "Z9": 25, "AA1": 26, "XFD1": maxSheetColumns - 1, "12": -1} {
+		if got, ok := columnIndex(ref); !ok || got != want {
+			t.Errorf("columnIndex(%q) = %d, %v; want %d", ref, got, ok, want)
+		}
+	}
+	for _, ref := range []string{"XFE1", "AAAA1", "ZZZZZZZZZZZZZZZ1"} {
+		if _, ok := columnIndex(ref); ok {
+			t.Errorf("columnIndex(%q) accepted", ref)
+		}
+	}
+}
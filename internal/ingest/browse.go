@@ -0,0 +1,258 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+type ListOptions struct {
+	Prefix string
+	Status string
+	Sort   string
+	Order  string
+	Limit  int
+	Offset int
+}
+
+type FileInfo struct {
+	Path         string     `json:"path"`
+	Status       string     `json:"status"`
+	Version      int        `json:"version"`
+	FileHash     string     `json:"file_hash"`
+	LastModified *time.Time `json:"last_modified"`
+	ChunkCount   int        `json:"chunk_count"`
+	DiskStatus   string     `json:"disk_status"`
+	Error        string     `json:"error,omitempty"`
+}
+
+type FileList struct {
+	Total  int        `json:"total"`
+	Limit  int        `json:"limit"`
+	Offset int        `json:"offset"`
+	Files  []FileInfo `json:"files"`
+}
+
+var fileSorts = map[string]string{
+	"modified": "f.last_modified",
+	"path":     "f.path",
+	"created":  "f.created_at",
+}
+
+func likePrefix(prefix string) string {
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return r.Replace(strings.TrimPrefix(filepath.ToSlash(prefix), "./")) + "%"
+}
+
+func (s *Service) ListFiles(ctx context.Context, opts ListOptions) (*FileList, error) {
+	column, ok := fileSorts[opts.Sort]
+	if !ok {
+		return nil, fmt.Errorf("invalid sort %q", opts.Sort)
+	}
+	order := "DESC NULLS LAST"
+	if opts.Order == "asc" {
+		order = "ASC NULLS FIRST"
+	}
+
+	list := &FileList{Limit: opts.Limit, Offset: opts.Offset, Files: []FileInfo{}}
+	err := s.db.QueryRow(ctx,
+		`SELECT COUNT(*) FROM files f
+		 WHERE f.path LIKE $1 AND ($2 = '' OR f.status = $2)`,
+		likePrefix(opts.Prefix), opts.Status,
+	).Scan(&list.Total)
+	if err != nil {
+		return nil, fmt.Errorf("count files: %w", err)
+	}
+
+	rows, err := s.db.Query(ctx,
+		`SELECT f.path, f.status, f.version, f.file_hash, f.last_modified, COUNT(c.id), COALESCE(f.error, ''), f.size, f.mtime
+		 FROM files f
+		 LEFT JOIN chunks c ON c.file_id = f.id
+		 WHERE f.path LIKE $1 AND ($2 = '' OR f.status = $2)
+		 GROUP BY f.id
+		 ORDER BY `+column+` `+order+`, f.path
+		 LIMIT $3 OFFSET $4`,
+		likePrefix(opts.Prefix), opts.Status, opts.Limit, opts.Offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list files: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var f FileInfo
+		var size *int64
+		var mtime *time.Time
+		if err := rows.Scan(&f.Path, &f.Status, &f.Version, &f.FileHash, &f.LastModified, &f.ChunkCount, &f.Error, &size, &mtime); err != nil {
+			return nil, err
+		}
+		f.DiskStatus = s.diskStatus(f.Path, f.FileHash, size, mtime)
+		list.Files = append(list.Files, f)
+	}
+	return list, rows.Err()
+}
+
+// diskStatus only hashes the file when its size matches but its mtime differs
+// from the ones recorded at index time
+func (s *Service) diskStatus(relPath, fileHash string, size *int64, mtime *time.Time) string {
+	absPath := filepath.Join(s.cfg.VaultRoot, filepath.FromSlash(relPath))
+	info, err := os.Stat(absPath)
+	switch {
+	case os.IsNotExist(err):
+		return "missing"
+	case err != nil:
+		return "unreadable"
+	case size == nil || mtime == nil:
+	case info.Size() != *size:
+		return "modified"
+	case info.ModTime().UTC().Truncate(time.Microsecond).Equal(*mtime):
+		return "ok"
+	}
+
+	hash, err := hashFile(absPath)
+	switch {
+	case err != nil:
+		return "unreadable"
+	case hash != fileHash:
+		return "modified"
+	}
+	return "ok"
+}
+
+type TreeNode struct {
+	Name      string      `json:"name"`
+	Path      string      `json:"path"`
+	Files     int         `json:"files"`
+	Chunks    int         `json:"chunks"`
+	Ready     int         `json:"ready"`
+	Pending   int         `json:"pending"`
+	Failed    int         `json:"failed"`
+	Missing   int         `json:"missing"`
+	Untracked int         `json:"untracked"`
+	Children  []*TreeNode `json:"children,omitempty"`
+
+	children map[string]*TreeNode
+}
+
+func (s *Service) Tree(ctx context.Context, prefix string) (*TreeNode, error) {
+	prefix = strings.Trim(filepath.ToSlash(filepath.Clean("/"+prefix)), "/")
+
+	rows, err := s.db.Query(ctx,
+		`SELECT f.path, f.status, COUNT(c.id)
+		 FROM files f
+		 LEFT JOIN chunks c ON c.file_id = f.id
+		 WHERE f.path LIKE $1
+		 GROUP BY f.id`,
+		likePrefix(prefix),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list files: %w", err)
+	}
+	defer rows.Close()
+
+	indexed := make(map[string]bool)
+	root := &TreeNode{Name: path.Base("/" + prefix), Path: prefix}
+	for rows.Next() {
+		var p, status string
+		var chunks int
+		if err := rows.Scan(&p, &status, &chunks); err != nil {
+			return nil, err
+		}
+		if prefix != "" && !strings.HasPrefix(p, prefix+"/") {
+			continue
+		}
+		indexed[p] = true
+
+		_, statErr := os.Stat(filepath.Join(s.cfg.VaultRoot, filepath.FromSlash(p)))
+		missing := os.IsNotExist(statErr)
+		root.add(p, prefix, func(n *TreeNode) {
+			n.Files++
+			n.Chunks += chunks
+			switch status {
+			case "ready":
+				n.Ready++
+			case "pending":
+				n.Pending++
+			case "error":
+				n.Failed++
+			}
+			if missing {
+				n.Missing++
+			}
+		})
+	}
+	rows.Close()
+
+	absRoot, err := sanitizePath(s.cfg.VaultRoot, prefix)
+	if prefix == "" {
+		absRoot, err = filepath.Clean(s.cfg.VaultRoot), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = filepath.WalkDir(absRoot, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if p != absRoot && ignoredDir(d.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !supportedFile(p) || ignoredPath(p) {
+			return nil
+		}
+		rel, err := filepath.Rel(s.cfg.VaultRoot, p)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if !indexed[rel] {
+			root.add(rel, prefix, func(n *TreeNode) { n.Untracked++ })
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	root.finish()
+	return root, nil
+}
+
+func (n *TreeNode) add(filePath, prefix string, count func(*TreeNode)) {
+	count(n)
+	dir := path.Dir(strings.TrimPrefix(strings.TrimPrefix(filePath, prefix), "/"))
+	if dir == "." {
+		return
+	}
+
+	cur := n
+	for _, part := range strings.Split(dir, "/") {
+		if cur.children == nil {
+			cur.children = make(map[string]*TreeNode)
+		}
+		child, ok := cur.children[part]
+		if !ok {
+			child = &TreeNode{Name: part, Path: path.Join(cur.Path, part)}
+			cur.children[part] = child
+		}
+		count(child)
+		cur = child
+	}
+}
+
+func (n *TreeNode) finish() {
+	for _, child := range n.children {
+		child.finish()
+		n.Children = append(n.Children, child)
+	}
+	sort.Slice(n.Children, func(i, j int) bool { return n.Children[i].Name < n.Children[j].Name })
+}
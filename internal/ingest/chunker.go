@@ -15,6 +15,7 @@ type Chunk struct {
 	ID       string
 	Text     string
 	Position string
+	Payload  map[string]any
 }
 
 func estimateTokens(text string) int {
@@ -28,6 +29,10 @@ func chunkID(filePath, text string) string {
 }
 
 func ChunkText(filePath, content string) []Chunk {
+	return chunkParagraphs(filePath, content, "")
+}
+
+func chunkParagraphs(filePath, content, prefix string) []Chunk {
 	content = strings.ReplaceAll(content, "\r\n", "\n")
 	paragraphs := strings.Split(content, "\n\n")
 	var chunks []Chunk
@@ -39,42 +44,47 @@ func ChunkText(filePath, content string) []Chunk {
 			continue
 		}
 
-		if estimateTokens(para) <= MaxTokens {
-			chunks = append(chunks, Chunk{
-				ID:       chunkID(filePath, para),
-				Text:     para,
-				Position: fmt.Sprintf("paragraph %d", i+1),
-			})
-			i++
-		} else {
-			maxChars := MaxTokens * 4
-			overlapChars := OverlapSize * 4
-			runes := []rune(para)
-
-			for start := 0; start < len(runes); start += maxChars - overlapChars {
-				end := start + maxChars
-				if end > len(runes) {
-					end = len(runes)
-				}
-
-				window := strings.TrimSpace(string(runes[start:end]))
-				if window == "" {
-					break
-				}
-
-				chunks = append(chunks, Chunk{
-					ID:       chunkID(filePath, window),
-					Text:     window,
-					Position: fmt.Sprintf("paragraph %d (window %d)", i+1, start/maxChars+1),
-				})
-
-				if end == len(runes) {
-					break
-				}
-			}
-			i++
-		}
+		chunks = append(chunks, windowChunks(filePath, para, fmt.Sprintf("%sparagraph %d", prefix, i+1))...)
+		i++
 	}
 
 	return chunks
 }
+
+func windowChunks(filePath, text, position string) []Chunk {
+	if estimateTokens(text) <= MaxTokens {
+		return []Chunk{{
+			ID:       chunkID(filePath, text),
+			Text:     text,
+			Position: position,
+		}}
+	}
+
+	maxChars := MaxTokens * 4
+	overlapChars := OverlapSize * 4
+	runes := []rune(text)
+
+	var chunks []Chunk
+	for start := 0; start < len(runes); start += maxChars - overlapChars {
+		end := start + maxChars
+		if end > len(runes) {
+			end = len(runes)
+		}
+
+		window := strings.TrimSpace(string(runes[start:end]))
+		if window == "" {
+			break
+		}
+
+		chunks = append(chunks, Chunk{
+			ID:       chunkID(filePath, window),
+			Text:     window,
+			Position: fmt.Sprintf("%s (window %d)", position, start/maxChars+1),
+		})
+
+		if end == len(runes) {
+			break
+		}
+	}
+	return chunks
+}
@@ -0,0 +1,352 @@
+package ingest
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"strings"
+)
+
+type codeBlock struct {
+	symbol string
+	start  int
+	end    int
+}
+
+func codeChunker(language string) func(string, []byte, chunkOptions) ([]Chunk, error) {
+	return func(filePath string, data []byte, _ chunkOptions) ([]Chunk, error) {
+		content := strings.ReplaceAll(string(data), "\r\n", "\n")
+		lines := strings.Split(content, "\n")
+
+		var blocks []codeBlock
+		switch language {
+		case "go":
+			blocks = goBlocks(filePath, content)
+			if blocks == nil {
+				blocks = braceBlocks(lines, language)
+			}
+		case "python":
+			blocks = indentBlocks(lines)
+		default:
+			blocks = braceBlocks(lines, language)
+		}
+
+		return codeChunks(filePath, language, lines, mergeAnonymousBlocks(blocks)), nil
+	}
+}
+
+func codeChunks(filePath, language string, lines []string, blocks []codeBlock) []Chunk {
+	maxChars := MaxTokens * 4
+
+	var chunks []Chunk
+	for _, b := range blocks {
+		for _, part := range splitBlock(lines, b, maxChars) {
+			text := strings.TrimRight(strings.Join(lines[part.start-1:part.end], "\n"), " \t\n")
+			if strings.TrimSpace(text) == "" {
+				continue
+			}
+
+			position := fmt.Sprintf("lines %d-%d", part.start, part.end)
+			if b.symbol != "" {
+				position = fmt.Sprintf("%s, %s", b.symbol, position)
+			}
+
+			chunks = append(chunks, Chunk{
+				ID:       chunkID(filePath, text),
+				Text:     text,
+				Position: position,
+				Payload: map[string]any{
+					"language":   language,
+					"symbol":     b.symbol,
+					"start_line": part.start,
+					"end_line":   part.end,
+				},
+			})
+		}
+	}
+	return chunks
+}
+
+func splitBlock(lines []string, b codeBlock, maxChars int) []codeBlock {
+	size := 0
+	for i := b.start - 1; i < b.end; i++ {
+		size += len(lines[i]) + 1
+	}
+	if size <= maxChars {
+		return []codeBlock{b}
+	}
+
+	overlapLines := 3
+	var parts []codeBlock
+	start := b.start
+	for start <= b.end {
+		end, size := start, 0
+		for end <= b.end && (size == 0 || size+len(lines[end-1])+1 <= maxChars) {
+			size += len(lines[end-1]) + 1
+			end++
+		}
+		parts = append(parts, codeBlock{symbol: b.symbol, start: start, end: end - 1})
+		if end > b.end {
+			break
+		}
+		start = max(end-overlapLines, start+1)
+	}
+	return parts
+}
+
+func mergeAnonymousBlocks(blocks []codeBlock) []codeBlock {
+	var out []codeBlock
+	for _, b := range blocks {
+		if n := len(out); n > 0 && b.symbol == "" && out[n-1].symbol == "" {
+			out[n-1].end = b.end
+			continue
+		}
+		out = append(out, b)
+	}
+	return out
+}
+
+func goBlocks(filePath, content string) []codeBlock {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filePath, content, parser.ParseComments)
+	if err != nil {
+		return nil
+	}
+
+	line := func(p token.Pos) int { return fset.Position(p).Line }
+
+	blocks := []codeBlock{{start: 1, end: line(f.Name.End())}}
+	for _, decl := range f.Decls {
+		start, end := decl.Pos(), decl.End()
+		var symbol string
+
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Doc != nil {
+				start = d.Doc.Pos()
+			}
+			symbol = "func " + d.Name.Name
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				symbol = "func " + receiverName(d.Recv.List[0].Type) + "." + d.Name.Name
+			}
+		case *ast.GenDecl:
+			if d.Doc != nil {
+				start = d.Doc.Pos()
+			}
+			if d.Tok != token.IMPORT {
+				var names []string
+				for _, spec := range d.Specs {
+					switch s := spec.(type) {
+					case *ast.TypeSpec:
+						names = append(names, s.Name.Name)
+					case *ast.ValueSpec:
+						for _, n := range s.Names {
+							names = append(names, n.Name)
+						}
+					}
+				}
+				symbol = d.Tok.String() + " " + strings.Join(names, ", ")
+			}
+		}
+
+		blocks = append(blocks, codeBlock{symbol: symbol, start: line(start), end: line(end)})
+	}
+	return blocks
+}
+
+func receiverName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(t.X)
+	case *ast.IndexExpr:
+		return receiverName(t.X)
+	case *ast.IndexListExpr:
+		return receiverName(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
+
+var (
+	pySymbol   = regexp.MustCompile(`^(?:async\s+)?(def|class)\s+(\w+)`)
+	tsSymbol   = regexp.MustCompile(`^(?:export\s+)?(?:default\s+)?(?:declare\s+)?(?:abstract\s+)?(?:async\s+)?(function\*?|class|interface|type|enum|namespace|const|let|var)\s+(\w+)`)
+	sqlSymbol  = regexp.MustCompile(`(?i)^(?:create|alter|drop)\s+(?:or\s+replace\s+)?(?:unique\s+)?(?:materialized\s+)?(table|view|function|procedure|index|trigger|type|sequence|schema|extension)\s+(?:if\s+(?:not\s+)?exists\s+)?([\w."]+)`)
+	tsContinue = regexp.MustCompile(`(,|=|\(|\[|\+|-|\*|&&|\|\||=>|\.|\?|:)$`)
+)
+
+func indentBlocks(lines []string) []codeBlock {
+	var blocks []codeBlock
+	var cur *codeBlock
+	decorated := false
+	commentStart := -1
+
+	for i, line := range lines {
+		n := i + 1
+		trimmed := strings.TrimSpace(line)
+		topLevel := trimmed != "" && line[0] != ' ' && line[0] != '\t'
+
+		if !topLevel || strings.HasPrefix(trimmed, ")") || strings.HasPrefix(trimmed, "]") || strings.HasPrefix(trimmed, "}") {
+			if cur != nil && trimmed != "" {
+				cur.end = n
+			}
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "#") {
+			if commentStart < 0 {
+				commentStart = n
+			}
+			continue
+		}
+
+		if cur != nil && decorated {
+			cur.end = n
+			if m := pySymbol.FindStringSubmatch(trimmed); m != nil {
+				cur.symbol = m[1] + " " + m[2]
+				decorated = false
+			}
+			continue
+		}
+
+		if cur != nil {
+			blocks = append(blocks, *cur)
+		}
+		start := n
+		if commentStart > 0 {
+			start = commentStart
+		}
+		commentStart = -1
+		cur = &codeBlock{start: start, end: n}
+		decorated = strings.HasPrefix(trimmed, "@")
+		if m := pySymbol.FindStringSubmatch(trimmed); m != nil {
+			cur.symbol = m[1] + " " + m[2]
+		}
+	}
+	if cur != nil {
+		blocks = append(blocks, *cur)
+	}
+	return blocks
+}
+
+func braceBlocks(lines []string, language string) []codeBlock {
+	var blocks []codeBlock
+	var cur *codeBlock
+	depth := 0
+	commentStart := -1
+	inBlockComment := false
+
+	for i, line := range lines {
+		n := i + 1
+		trimmed := strings.TrimSpace(line)
+
+		if cur == nil {
+			if trimmed == "" {
+				commentStart = -1
+				continue
+			}
+			if inBlockComment || isLineComment(trimmed, language) {
+				if commentStart < 0 {
+					commentStart = n
+				}
+				if strings.HasPrefix(trimmed, "/*") && !strings.Contains(trimmed, "*/") {
+					inBlockComment = true
+				} else if inBlockComment && strings.Contains(trimmed, "*/") {
+					inBlockComment = false
+				}
+				continue
+			}
+
+			start := n
+			if commentStart > 0 {
+				start = commentStart
+			}
+			commentStart = -1
+			cur = &codeBlock{start: start, symbol: codeSymbol(trimmed, language)}
+		}
+
+		depth += bracketDelta(line, language)
+		cur.end = n
+
+		if depth > 0 {
+			continue
+		}
+		depth = 0
+
+		ended := strings.HasSuffix(trimmed, ";")
+		if language != "sql" {
+			ended = ended || !tsContinue.MatchString(trimmed)
+		}
+		if ended {
+			blocks = append(blocks, *cur)
+			cur = nil
+		}
+	}
+	if cur != nil {
+		blocks = append(blocks, *cur)
+	}
+	return blocks
+}
+
+func isLineComment(trimmed, language string) bool {
+	if language == "sql" && strings.HasPrefix(trimmed, "--") {
+		return true
+	}
+	return strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "/*") || strings.HasPrefix(trimmed, "*")
+}
+
+func codeSymbol(trimmed, language string) string {
+	switch language {
+	case "sql":
+		if m := sqlSymbol.FindStringSubmatch(trimmed); m != nil {
+			return strings.ToLower(m[1]) + " " + m[2]
+		}
+	default:
+		if m := tsSymbol.FindStringSubmatch(trimmed); m != nil {
+			return m[1] + " " + m[2]
+		}
+	}
+	return ""
+}
+
+func bracketDelta(line, language string) int {
+	delta := 0
+	var quote rune
+	escaped := false
+	runes := []rune(line)
+
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		if quote != 0 {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == quote:
+				quote = 0
+			}
+			continue
+		}
+
+		switch c {
+		case '"', '\'', '`':
+			quote = c
+		case '/':
+			if i+1 < len(runes) && runes[i+1] == '/' && language != "sql" {
+				return delta
+			}
+		case '-':
+			if i+1 < len(runes) && runes[i+1] == '-' && language == "sql" {
+				return delta
+			}
+		case '{', '(', '[':
+			delta++
+		case '}', ')', ']':
+			delta--
+		}
+	}
+	return delta
+}
@@ -0,0 +1,202 @@
+package ingest
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/SzymonLeja/local-memory-engine/internal/extract"
+)
+
+func chunkTable(filePath, sheet string, rows [][]string) []Chunk {
+	headerIdx := -1
+	for i, row := range rows {
+		if !extract.EmptyRow(row) {
+			headerIdx = i
+			break
+		}
+	}
+	if headerIdx < 0 {
+		return nil
+	}
+
+	head := strings.Join(rows[headerIdx], " | ")
+	label := ""
+	if sheet != "" {
+		head = fmt.Sprintf("Sheet: %s\n%s", sheet, head)
+		label = "sheet " + sheet + ", "
+	}
+
+	var chunks []Chunk
+	var lines []string
+	first, last := 0, 0
+
+	flush := func() {
+		if len(lines) == 0 {
+			return
+		}
+		text := head + "\n" + strings.Join(lines, "\n")
+		chunks = append(chunks, Chunk{
+			ID:       chunkID(filePath, text),
+			Text:     text,
+			Position: fmt.Sprintf("%srows %d-%d", label, first, last),
+		})
+		lines = nil
+	}
+
+	for i := headerIdx + 1; i < len(rows); i++ {
+		row := rows[i]
+		if extract.EmptyRow(row) {
+			continue
+		}
+		line := strings.Join(row, " | ")
+		if len(lines) > 0 && estimateTokens(head+strings.Join(lines, "\n")+line) > MaxTokens {
+			flush()
+		}
+		if len(lines) == 0 {
+			first = i + 1
+		}
+		lines = append(lines, line)
+		last = i + 1
+	}
+	flush()
+
+	if len(chunks) == 0 {
+		chunks = append(chunks, Chunk{
+			ID:       chunkID(filePath, head),
+			Text:     head,
+			Position: fmt.Sprintf("%srows %d-%d", label, headerIdx+1, headerIdx+1),
+		})
+	}
+	return chunks
+}
+
+func chunkCSV(filePath string, data []byte, _ chunkOptions) ([]Chunk, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("parse csv: %w", err)
+	}
+	return chunkTable(filePath, "", rows), nil
+}
+
+type jsonUnit struct {
+	path  string
+	lines []string
+}
+
+func chunkJSON(filePath string, data []byte, _ chunkOptions) ([]Chunk, error) {
+	var root any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&root); err != nil {
+		// JSONC, JSON5 and other near-JSON files are still worth indexing
+		return ChunkText(filePath, string(data)), nil
+	}
+
+	var units []jsonUnit
+	jsonUnits("$", root, &units)
+
+	var chunks []Chunk
+	var lines []string
+	var firstPath, lastPath string
+
+	flush := func() {
+		if len(lines) == 0 {
+			return
+		}
+		position := firstPath
+		if lastPath != firstPath {
+			position = firstPath + " – " + lastPath
+		}
+		chunks = append(chunks, windowChunks(filePath, strings.Join(lines, "\n"), position)...)
+		lines = nil
+	}
+
+	for _, u := range units {
+		text := strings.Join(u.lines, "\n")
+		if len(lines) > 0 && estimateTokens(strings.Join(lines, "\n")+text) > MaxTokens {
+			flush()
+		}
+		if len(lines) == 0 {
+			firstPath = u.path
+		}
+		lines = append(lines, u.lines...)
+		lastPath = u.path
+	}
+	flush()
+
+	return chunks, nil
+}
+
+func jsonUnits(path string, v any, out *[]jsonUnit) {
+	lines := flattenJSON(path, v, nil)
+	if estimateTokens(strings.Join(lines, "\n")) <= MaxTokens {
+		*out = append(*out, jsonUnit{path: path, lines: lines})
+		return
+	}
+
+	switch t := v.(type) {
+	case map[string]any:
+		for _, k := range sortedKeys(t) {
+			jsonUnits(jsonKeyPath(path, k), t[k], out)
+		}
+	case []any:
+		for i, item := range t {
+			jsonUnits(fmt.Sprintf("%s[%d]", path, i), item, out)
+		}
+	default:
+		*out = append(*out, jsonUnit{path: path, lines: lines})
+	}
+}
+
+func flattenJSON(path string, v any, lines []string) []string {
+	switch t := v.(type) {
+	case map[string]any:
+		if len(t) == 0 {
+			return append(lines, path+": {}")
+		}
+		for _, k := range sortedKeys(t) {
+			lines = flattenJSON(jsonKeyPath(path, k), t[k], lines)
+		}
+		return lines
+	case []any:
+		if len(t) == 0 {
+			return append(lines, path+": []")
+		}
+		for i, item := range t {
+			lines = flattenJSON(fmt.Sprintf("%s[%d]", path, i), item, lines)
+		}
+		return lines
+	case nil:
+		return append(lines, path+": null")
+	case string:
+		return append(lines, path+": "+t)
+	default:
+		return append(lines, fmt.Sprintf("%s: %v", path, t))
+	}
+}
+
+func jsonKeyPath(path, key string) string {
+	for _, c := range key {
+		if !(c == '_' || c == '-' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')) {
+			return fmt.Sprintf("%s[%q]", path, key)
+		}
+	}
+	return path + "." + key
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,154 @@
+package ingest
+
+import (
+	"fmt"
+	"strings"
+)
+
+const diffContext = 3
+
+type diffLine struct {
+	op   byte
+	text string
+}
+
+const maxDiffCells = 4_000_000
+
+func diffLines(a, b []string) []diffLine {
+	var head, tail []diffLine
+	for len(a) > 0 && len(b) > 0 && a[0] == b[0] {
+		head = append(head, diffLine{' ', a[0]})
+		a, b = a[1:], b[1:]
+	}
+	k := 0
+	for k < len(a) && k < len(b) && a[len(a)-1-k] == b[len(b)-1-k] {
+		k++
+	}
+	for _, l := range a[len(a)-k:] {
+		tail = append(tail, diffLine{' ', l})
+	}
+	a, b = a[:len(a)-k], b[:len(b)-k]
+
+	out := head
+	if len(a)*len(b) > maxDiffCells {
+		for _, l := range a {
+			out = append(out, diffLine{'-', l})
+		}
+		for _, l := range b {
+			out = append(out, diffLine{'+', l})
+		}
+	} else {
+		out = append(out, lcsDiff(a, b)...)
+	}
+	return append(out, tail...)
+}
+
+func lcsDiff(a, b []string) []diffLine {
+	n, m := len(a), len(b)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var out []diffLine
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			out = append(out, diffLine{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			out = append(out, diffLine{'-', a[i]})
+			i++
+		default:
+			out = append(out, diffLine{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		out = append(out, diffLine{'-', a[i]})
+	}
+	for ; j < m; j++ {
+		out = append(out, diffLine{'+', b[j]})
+	}
+	return out
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(strings.ReplaceAll(s, "\r\n", "\n"), "\n"), "\n")
+}
+
+func unifiedDiff(fromName, toName, from, to string) string {
+	lines := diffLines(splitLines(from), splitLines(to))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", fromName, toName)
+
+	for start := 0; start < len(lines); {
+		for start < len(lines) && lines[start].op == ' ' {
+			start++
+		}
+		if start == len(lines) {
+			break
+		}
+
+		lo := max(start-diffContext, 0)
+		hi := start
+		for hi < len(lines) {
+			if lines[hi].op != ' ' {
+				hi++
+				continue
+			}
+			run := hi
+			for run < len(lines) && lines[run].op == ' ' {
+				run++
+			}
+			if run == len(lines) || run-hi > 2*diffContext {
+				hi = min(hi+diffContext, len(lines))
+				break
+			}
+			hi = run
+		}
+
+		aStart, bStart := 1, 1
+		for _, l := range lines[:lo] {
+			if l.op != '+' {
+				aStart++
+			}
+			if l.op != '-' {
+				bStart++
+			}
+		}
+		aLen, bLen := 0, 0
+		for _, l := range lines[lo:hi] {
+			if l.op != '+' {
+				aLen++
+			}
+			if l.op != '-' {
+				bLen++
+			}
+		}
+
+		fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@\n", aStart, aLen, bStart, bLen)
+		for _, l := range lines[lo:hi] {
+			b.WriteByte(l.op)
+			b.WriteString(l.text)
+			b.WriteByte('\n')
+		}
+		start = hi
+	}
+	return b.String()
+}
@@ -0,0 +1,153 @@
+package ingest
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/SzymonLeja/local-memory-engine/internal/extract"
+)
+
+type format struct {
+	chunk func(filePath string, data []byte, opts chunkOptions) ([]Chunk, error)
+	text  func(data []byte) (string, error)
+}
+
+type chunkOptions struct {
+	NotebookOutputs bool
+}
+
+var formats = map[string]format{
+	".md":    {chunk: chunkMarkdown, text: plainText},
+	".pdf":   {chunk: chunkPDF, text: pdfText},
+	".docx":  {chunk: sectionsChunker(extract.DOCXSections), text: sectionsText(extract.DOCXSections)},
+	".odt":   {chunk: sectionsChunker(extract.ODTSections), text: sectionsText(extract.ODTSections)},
+	".xlsx":  {chunk: chunkXLSX, text: xlsxText},
+	".go":    {chunk: codeChunker("go"), text: plainText},
+	".py":    {chunk: codeChunker("python"), text: plainText},
+	".ts":    {chunk: codeChunker("typescript"), text: plainText},
+	".sql":   {chunk: codeChunker("sql"), text: plainText},
+	".ipynb": {chunk: chunkNotebook, text: notebookText},
+	".csv":   {chunk: chunkCSV, text: plainText},
+	".json":  {chunk: chunkJSON, text: plainText},
+	".srt":   {chunk: chunkTranscript, text: transcriptText},
+	".vtt":   {chunk: chunkTranscript, text: transcriptText},
+}
+
+func supportedFile(path string) bool {
+	_, ok := formats[strings.ToLower(filepath.Ext(path))]
+	return ok
+}
+
+func supportedFormat(name string) bool {
+	_, ok := formats["."+strings.ToLower(name)]
+	return ok
+}
+
+// extractors parse untrusted vault files and run on the watcher's goroutines
+// too, so a parser bug must fail the file instead of the process
+func recoverExtract(filePath string, err *error) {
+	if r := recover(); r != nil {
+		*err = fmt.Errorf("extract %s: panic: %v", filePath, r)
+	}
+}
+
+func chunkFile(filePath string, data []byte, opts chunkOptions) (chunks []Chunk, err error) {
+	f, ok := formats[strings.ToLower(filepath.Ext(filePath))]
+	if !ok {
+		return nil, fmt.Errorf("unsupported file type: %s", filePath)
+	}
+	defer recoverExtract(filePath, &err)
+	return f.chunk(filePath, data, opts)
+}
+
+func extractText(filePath string, data []byte) (text string, err error) {
+	f, ok := formats[strings.ToLower(filepath.Ext(filePath))]
+	if !ok {
+		return "", fmt.Errorf("unsupported file type: %s", filePath)
+	}
+	defer recoverExtract(filePath, &err)
+	return f.text(data)
+}
+
+func plainText(data []byte) (string, error) {
+	return string(data), nil
+}
+
+func chunkMarkdown(filePath string, data []byte, _ chunkOptions) ([]Chunk, error) {
+	_, body := parseFrontMatter(string(data))
+	return ChunkText(filePath, body), nil
+}
+
+func chunkPDF(filePath string, data []byte, _ chunkOptions) ([]Chunk, error) {
+	pages, err := extract.PDFPages(data)
+	if err != nil {
+		return nil, fmt.Errorf("extract pdf: %w", err)
+	}
+
+	var chunks []Chunk
+	for i, page := range pages {
+		chunks = append(chunks, chunkParagraphs(filePath, page, fmt.Sprintf("page %d, ", i+1))...)
+	}
+	return chunks, nil
+}
+
+func pdfText(data []byte) (string, error) {
+	pages, err := extract.PDFPages(data)
+	if err != nil {
+		return "", fmt.Errorf("extract pdf: %w", err)
+	}
+	return strings.Join(pages, "\n\n"), nil
+}
+
+func sectionsChunker(parse func([]byte) ([]extract.Section, error)) func(string, []byte, chunkOptions) ([]Chunk, error) {
+	return func(filePath string, data []byte, _ chunkOptions) ([]Chunk, error) {
+		sections, err := parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("extract sections: %w", err)
+		}
+
+		var chunks []Chunk
+		for _, sec := range sections {
+			prefix := ""
+			paragraphs := sec.Paragraphs
+			if sec.Heading != "" {
+				prefix = fmt.Sprintf("section %q, ", sec.Heading)
+				paragraphs = append([]string{sec.Heading}, paragraphs...)
+			}
+			chunks = append(chunks, chunkParagraphs(filePath, strings.Join(paragraphs, "\n\n"), prefix)...)
+		}
+		return chunks, nil
+	}
+}
+
+func sectionsText(parse func([]byte) ([]extract.Section, error)) func([]byte) (string, error) {
+	return func(data []byte) (string, error) {
+		sections, err := parse(data)
+		if err != nil {
+			return "", fmt.Errorf("extract sections: %w", err)
+		}
+		return extract.SectionsText(sections), nil
+	}
+}
+
+func chunkXLSX(filePath string, data []byte, _ chunkOptions) ([]Chunk, error) {
+	sheets, err := extract.XLSXSheets(data)
+	if err != nil {
+		return nil, fmt.Errorf("extract xlsx: %w", err)
+	}
+
+	var chunks []Chunk
+	for _, sheet := range sheets {
+		chunks = append(chunks, chunkTable(filePath, "sheet "+sheet.Name, sheet.Rows)...)
+	}
+	return chunks, nil
+}
+
+func xlsxText(data []byte) (string, error) {
+	sheets, err := extract.XLSXSheets(data)
+	if err != nil {
+		return "", fmt.Errorf("extract xlsx: %w", err)
+	}
+	return extract.SheetsText(sheets), nil
+}
@@ -0,0 +1,120 @@
+package ingest
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+var inlineTag = regexp.MustCompile(`(?:^|\s)#([\p{L}\p{N}_][\p{L}\p{N}_/-]*)`)
+
+func splitFrontMatter(content string) (string, string) {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	if !strings.HasPrefix(content, "---\n") {
+		return "", content
+	}
+
+	rest := content[len("---\n"):]
+	end := strings.Index(rest, "\n---")
+	if end < 0 {
+		return "", content
+	}
+
+	after := rest[end+len("\n---"):]
+	if after != "" && after[0] != '\n' {
+		return "", content
+	}
+	return rest[:end], strings.TrimPrefix(after, "\n")
+}
+
+func parseFrontMatter(content string) (map[string]any, string) {
+	raw, body := splitFrontMatter(content)
+
+	meta := make(map[string]any)
+	if raw != "" {
+		if err := yaml.Unmarshal([]byte(raw), &meta); err != nil {
+			return map[string]any{}, content
+		}
+	}
+	for k, v := range meta {
+		if t, ok := v.(time.Time); ok {
+			meta[k] = t.Format(time.DateOnly)
+		}
+	}
+
+	tags := normalizeTags(meta["tags"])
+	seen := make(map[string]bool, len(tags))
+	for _, t := range tags {
+		seen[t] = true
+	}
+	for _, t := range inlineTags(body) {
+		if !seen[t] {
+			seen[t] = true
+			tags = append(tags, t)
+		}
+	}
+	if len(tags) > 0 {
+		meta["tags"] = tags
+	}
+	if aliases, ok := meta["aliases"]; ok {
+		meta["aliases"] = normalizeList(aliases)
+	}
+
+	return meta, body
+}
+
+func normalizeList(v any) []string {
+	var out []string
+	switch t := v.(type) {
+	case string:
+		for _, part := range strings.Split(t, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				out = append(out, part)
+			}
+		}
+	case []any:
+		for _, item := range t {
+			if s, ok := item.(string); ok && strings.TrimSpace(s) != "" {
+				out = append(out, strings.TrimSpace(s))
+			}
+		}
+	}
+	return out
+}
+
+func normalizeTags(v any) []string {
+	var tags []string
+	for _, t := range normalizeList(v) {
+		tags = append(tags, strings.TrimPrefix(t, "#"))
+	}
+	return tags
+}
+
+func inlineTags(body string) []string {
+	var tags []string
+	inFence := false
+	for _, line := range strings.Split(body, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		for _, m := range inlineTag.FindAllStringSubmatch(line, -1) {
+			tags = append(tags, m[1])
+		}
+	}
+	return tags
+}
+
+func fileMetadata(filePath string, data []byte) map[string]any {
+	if strings.ToLower(filepath.Ext(filePath)) != ".md" {
+		return nil
+	}
+	meta, _ := parseFrontMatter(string(data))
+	return meta
+}
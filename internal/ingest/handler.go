@@ -6,7 +6,9 @@ import (
 	"io"
 	"net/http"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -63,12 +65,12 @@ func (s *Service) GetFileHandler(w http.ResponseWriter, r *http.Request) {
 	if format == "" {
 		format = "md"
 	}
-	if format != "md" {
-		http.Error(w, "only format=md is supported in v0.1", http.StatusBadRequest)
+	if !supportedFormat(format) {
+		http.Error(w, "unsupported format: "+format, http.StatusBadRequest)
 		return
 	}
 
-	content, filePath, updatedAt, err := s.GetFile(r.Context(), filename, format, path)
+	file, err := s.GetFile(r.Context(), filename, format, path)
 	if err != nil {
 		if errors.Is(err, ErrNotFound) {
 			http.Error(w, "file not found", http.StatusNotFound)
@@ -85,15 +87,90 @@ func (s *Service) GetFileHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", `"`+file.ETag+`"`)
 	json.NewEncoder(w).Encode(map[string]any{
 		"filename":   filename,
 		"format":     format,
-		"path":       filePath,
-		"content":    content,
-		"updated_at": updatedAt,
+		"path":       file.Path,
+		"content":    file.Content,
+		"metadata":   file.Metadata,
+		"updated_at": file.UpdatedAt,
+		"etag":       file.ETag,
 	})
 }
 
+func (s *Service) DeleteFileHandler(w http.ResponseWriter, r *http.Request) {
+	filename := chi.URLParam(r, "filename")
+	format := r.URL.Query().Get("format")
+	path := r.URL.Query().Get("path")
+	soft := r.URL.Query().Get("soft") == "true"
+
+	if format == "" {
+		format = "md"
+	}
+	if !supportedFormat(format) {
+		http.Error(w, "unsupported format: "+format, http.StatusBadRequest)
+		return
+	}
+
+	result, err := s.DeleteFile(r.Context(), filename, format, path, soft)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			http.Error(w, "file not found", http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, ErrMultipleMatches) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusMultipleChoices)
+			json.NewEncoder(w).Encode(err.(*MultipleMatchesError).Matches)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+func (s *Service) MoveFileHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Source       string `json:"source"`
+		Destination  string `json:"destination"`
+		RewriteLinks bool   `json:"rewrite_links"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.Source == "" || req.Destination == "" {
+		http.Error(w, "source and destination are required", http.StatusBadRequest)
+		return
+	}
+
+	result, err := s.MoveFile(r.Context(), req.Source, req.Destination, req.RewriteLinks)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			http.Error(w, "file not found", http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, ErrDestinationExists) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if errors.Is(err, ErrIgnoredDestination) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
 func (s *Service) PatchIngestHandler(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		Filename string `json:"filename"`
@@ -101,12 +178,34 @@ func (s *Service) PatchIngestHandler(w http.ResponseWriter, r *http.Request) {
 		Path     string `json:"path"`
 		Content  string `json:"content"`
 		Append   string `json:"append"`
+
+		Op            string `json:"op"`
+		Heading       string `json:"heading"`
+		Text          string `json:"text"`
+		Find          string `json:"find"`
+		Replace       string `json:"replace"`
+		ExpectedCount int    `json:"expected_count"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "invalid request body", http.StatusBadRequest)
 		return
 	}
 
+	edit := Edit{
+		Op:            req.Op,
+		Heading:       req.Heading,
+		Text:          req.Text,
+		Find:          req.Find,
+		Replace:       req.Replace,
+		ExpectedCount: req.ExpectedCount,
+	}
+	if edit.Op == "" {
+		edit.Op, edit.Text = "overwrite", req.Content
+		if req.Append != "" {
+			edit.Op, edit.Text = "append", req.Append
+		}
+	}
+
 	if req.Filename == "" {
 		http.Error(w, "filename is required", http.StatusBadRequest)
 		return
@@ -115,17 +214,39 @@ func (s *Service) PatchIngestHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "only format=md is supported in v0.1", http.StatusBadRequest)
 		return
 	}
-	if req.Content == "" && req.Append == "" {
+	if !EditOps[edit.Op] {
+		http.Error(w, "op must be one of: overwrite, append, prepend, insert_under_heading, replace_section, replace_text", http.StatusBadRequest)
+		return
+	}
+	switch {
+	case req.Op == "" && req.Content == "" && req.Append == "":
 		http.Error(w, "content or append is required", http.StatusBadRequest)
 		return
+	case req.Op != "" && edit.Op != "replace_text" && edit.Text == "":
+		http.Error(w, "text is required", http.StatusBadRequest)
+		return
+	case (edit.Op == "insert_under_heading" || edit.Op == "replace_section") && edit.Heading == "":
+		http.Error(w, "heading is required", http.StatusBadRequest)
+		return
+	case edit.Op == "replace_text" && edit.Find == "":
+		http.Error(w, "find is required", http.StatusBadRequest)
+		return
 	}
 
-	result, err := s.EditFile(r.Context(), req.Filename, req.Format, req.Path, req.Content, req.Append)
+	result, err := s.EditFile(r.Context(), req.Filename, req.Format, req.Path, edit, r.Header.Get("If-Match"))
 	if err != nil {
 		if errors.Is(err, ErrNotFound) {
 			http.Error(w, "file not found", http.StatusNotFound)
 			return
 		}
+		if errors.Is(err, ErrHeadingNotFound) || errors.Is(err, ErrMatchCount) {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		if errors.Is(err, ErrPreconditionFailed) {
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
+			return
+		}
 		if errors.Is(err, ErrMultipleMatches) {
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusMultipleChoices)
@@ -137,6 +258,7 @@ func (s *Service) PatchIngestHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", `"`+result.FileHash+`"`)
 	json.NewEncoder(w).Encode(result)
 }
 
@@ -154,8 +276,8 @@ func (s *Service) ingestMultipart(w http.ResponseWriter, r *http.Request) {
 	defer file.Close()
 
 	ext := strings.ToLower(filepath.Ext(header.Filename))
-	if ext != ".md" {
-		http.Error(w, "only .md files are supported in v0.1", http.StatusBadRequest)
+	if !supportedFile(header.Filename) {
+		http.Error(w, "unsupported file type: "+ext, http.StatusBadRequest)
 		return
 	}
 
@@ -171,7 +293,7 @@ func (s *Service) ingestMultipart(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := s.IngestDirect(r.Context(), filename, path, string(content))
+	result, err := s.ingestFile(r.Context(), filename+ext, path, content)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -180,3 +302,234 @@ func (s *Service) ingestMultipart(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(result)
 }
+
+func (s *Service) ListFilesHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	opts := ListOptions{
+		Prefix: q.Get("prefix"),
+		Status: q.Get("status"),
+		Sort:   q.Get("sort"),
+		Order:  q.Get("order"),
+		Limit:  50,
+	}
+	if opts.Sort == "" {
+		opts.Sort = "modified"
+	}
+	if _, ok := fileSorts[opts.Sort]; !ok {
+		http.Error(w, "sort must be modified, path or created", http.StatusBadRequest)
+		return
+	}
+	if opts.Status != "" && opts.Status != "pending" && opts.Status != "ready" && opts.Status != "error" {
+		http.Error(w, "status must be pending, ready or error", http.StatusBadRequest)
+		return
+	}
+	if v, err := strconv.Atoi(q.Get("limit")); err == nil && v > 0 {
+		opts.Limit = min(v, 500)
+	}
+	if v, err := strconv.Atoi(q.Get("offset")); err == nil && v > 0 {
+		opts.Offset = v
+	}
+
+	list, err := s.ListFiles(r.Context(), opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+func (s *Service) TreeHandler(w http.ResponseWriter, r *http.Request) {
+	tree, err := s.Tree(r.Context(), r.URL.Query().Get("prefix"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tree)
+}
+
+func fileParams(r *http.Request) (filename, format, path string) {
+	format = r.URL.Query().Get("format")
+	if format == "" {
+		format = "md"
+	}
+	return chi.URLParam(r, "filename"), format, r.URL.Query().Get("path")
+}
+
+func writeFileError(w http.ResponseWriter, err error) {
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, "file not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, ErrVersionNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, ErrMultipleMatches) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusMultipleChoices)
+		json.NewEncoder(w).Encode(err.(*MultipleMatchesError).Matches)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+func (s *Service) VersionsHandler(w http.ResponseWriter, r *http.Request) {
+	filename, format, path := fileParams(r)
+
+	relPath, versions, err := s.Versions(r.Context(), filename, format, path)
+	if err != nil {
+		writeFileError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"path":     relPath,
+		"versions": versions,
+	})
+}
+
+func (s *Service) GetVersionHandler(w http.ResponseWriter, r *http.Request) {
+	filename, format, path := fileParams(r)
+	version, err := strconv.Atoi(chi.URLParam(r, "version"))
+	if err != nil {
+		http.Error(w, "version must be a number", http.StatusBadRequest)
+		return
+	}
+
+	relPath, content, err := s.GetVersion(r.Context(), filename, format, path, version)
+	if err != nil {
+		writeFileError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"path":    relPath,
+		"version": version,
+		"content": content,
+	})
+}
+
+func (s *Service) DiffHandler(w http.ResponseWriter, r *http.Request) {
+	filename, format, path := fileParams(r)
+	from, _ := strconv.Atoi(r.URL.Query().Get("from"))
+	to, _ := strconv.Atoi(r.URL.Query().Get("to"))
+
+	relPath, diff, err := s.DiffVersions(r.Context(), filename, format, path, from, to)
+	if err != nil {
+		writeFileError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"path": relPath,
+		"diff": diff,
+	})
+}
+
+func (s *Service) RestoreHandler(w http.ResponseWriter, r *http.Request) {
+	filename, format, path := fileParams(r)
+
+	var req struct {
+		Version int `json:"version"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Version <= 0 {
+		http.Error(w, "version is required", http.StatusBadRequest)
+		return
+	}
+
+	result, err := s.RestoreVersion(r.Context(), filename, format, path, req.Version)
+	if err != nil {
+		writeFileError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+func (s *Service) CreateNoteHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Template string            `json:"template"`
+		Title    string            `json:"title"`
+		Path     string            `json:"path"`
+		Vars     map[string]string `json:"vars"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.Template == "" || req.Title == "" {
+		http.Error(w, "template and title are required", http.StatusBadRequest)
+		return
+	}
+
+	result, err := s.CreateNote(r.Context(), NoteRequest{
+		Template: req.Template,
+		Title:    req.Title,
+		Path:     req.Path,
+		Vars:     req.Vars,
+	})
+	if err != nil {
+		if errors.Is(err, ErrTemplateNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, ErrNoteExists) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if errors.Is(err, ErrInvalidTitle) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", `"`+result.FileHash+`"`)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(result)
+}
+
+func (s *Service) DailyNoteHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Date string `json:"date"`
+		Text string `json:"text"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	day := time.Now()
+	if req.Date != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", req.Date, time.Local)
+		if err != nil {
+			http.Error(w, "date must be YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		day = parsed
+	}
+
+	result, err := s.AppendDaily(r.Context(), day, req.Text)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", `"`+result.FileHash+`"`)
+	if result.Created {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(result)
+}
@@ -1,6 +1,9 @@
 package ingest
 
 import (
+	"sync"
+	"time"
+
 	"github.com/SzymonLeja/local-memory-engine/internal/config"
 	"github.com/SzymonLeja/local-memory-engine/internal/embeddings"
 	"github.com/SzymonLeja/local-memory-engine/internal/vector"
@@ -11,14 +14,23 @@ type Service struct {
 	db     *pgxpool.Pool
 	cfg    *config.Config
 	ollama *embeddings.OllamaClient
+	llm    *embeddings.OllamaClient
 	qdrant *vector.QdrantClient
+
+	fileLocks sync.Map
+
+	rollupMu     sync.Mutex
+	rollupRun    sync.Mutex
+	rollupTimer  *time.Timer
+	dirtyFolders map[string]bool
 }
 
 func NewService(
 	db *pgxpool.Pool,
 	cfg *config.Config,
 	ollama *embeddings.OllamaClient,
+	llm *embeddings.OllamaClient,
 	qdrant *vector.QdrantClient,
 ) *Service {
-	return &Service{db: db, cfg: cfg, ollama: ollama, qdrant: qdrant}
+	return &Service{db: db, cfg: cfg, ollama: ollama, llm: llm, qdrant: qdrant}
 }
@@ -0,0 +1,246 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+type Link struct {
+	Target string
+	Alias  string
+	Anchor string
+	Kind   string
+}
+
+var (
+	wikiLink     = regexp.MustCompile(`!?\[\[([^\]\|#]*)(?:#([^\]\|]*))?(?:\|([^\]]*))?\]\]`)
+	markdownLink = regexp.MustCompile(`!?\[([^\]]*)\]\(<?([^)\s>]+)>?(?:\s+"[^"]*")?\)`)
+)
+
+// linkable drops links that can never resolve to an indexed note: embeds of
+// attachments and markdown links to unsupported files
+func linkable(match, target, kind string) bool {
+	ext := strings.ToLower(path.Ext(target))
+	if strings.HasPrefix(match, "!") {
+		return ext == "" || ext == ".md"
+	}
+	return kind == "wikilink" || ext == "" || supportedFile(target)
+}
+
+func parseLinks(body string) []Link {
+	var links []Link
+	inFence := false
+
+	for _, line := range strings.Split(body, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+
+		for _, m := range wikiLink.FindAllStringSubmatch(line, -1) {
+			target := strings.TrimSpace(m[1])
+			if target == "" || !linkable(m[0], target, "wikilink") {
+				continue
+			}
+			links = append(links, Link{
+				Target: target,
+				Anchor: strings.TrimSpace(m[2]),
+				Alias:  strings.TrimSpace(m[3]),
+				Kind:   "wikilink",
+			})
+		}
+
+		for _, m := range markdownLink.FindAllStringSubmatch(line, -1) {
+			raw := m[2]
+			if strings.Contains(raw, "://") || strings.HasPrefix(raw, "mailto:") || strings.HasPrefix(raw, "#") {
+				continue
+			}
+			target, anchor, _ := strings.Cut(raw, "#")
+			if decoded, err := url.PathUnescape(target); err == nil {
+				target = decoded
+			}
+			if !linkable(m[0], target, "markdown") {
+				continue
+			}
+			links = append(links, Link{
+				Target: target,
+				Anchor: anchor,
+				Alias:  strings.TrimSpace(m[1]),
+				Kind:   "markdown",
+			})
+		}
+	}
+	return links
+}
+
+func (s *Service) resolveLink(ctx context.Context, sourcePath string, link Link) (string, bool) {
+	target := strings.TrimPrefix(filepath.ToSlash(link.Target), "/")
+	if path.Ext(target) == "" {
+		target += ".md"
+	}
+
+	var candidates []string
+	if link.Kind == "markdown" {
+		candidates = append(candidates, path.Clean(path.Join(path.Dir(sourcePath), target)))
+	}
+	candidates = append(candidates, path.Clean(target))
+
+	for _, c := range candidates {
+		var found string
+		err := s.db.QueryRow(ctx, `SELECT path FROM files WHERE path = $1`, c).Scan(&found)
+		if err == nil {
+			return found, true
+		}
+	}
+
+	var found string
+	err := s.db.QueryRow(ctx,
+		`SELECT path FROM files
+		 WHERE lower(path) = lower($1) OR lower(path) LIKE '%/' || lower($1)
+		 ORDER BY CASE WHEN path LIKE $2 || '/%' THEN 0 ELSE 1 END, length(path)
+		 LIMIT 1`,
+		target, path.Dir(sourcePath),
+	).Scan(&found)
+	if err == nil {
+		return found, true
+	}
+
+	err = s.db.QueryRow(ctx,
+		`SELECT path FROM files
+		 WHERE metadata->'aliases' ? $1
+		 ORDER BY length(path)
+		 LIMIT 1`,
+		link.Target,
+	).Scan(&found)
+	if err == nil {
+		return found, true
+	}
+
+	return "", false
+}
+
+func (s *Service) updateLinks(ctx context.Context, fileID, relPath string, links []Link) error {
+	if _, err := s.db.Exec(ctx, `DELETE FROM links WHERE source_file_id = $1`, fileID); err != nil {
+		return fmt.Errorf("delete links: %w", err)
+	}
+
+	for _, link := range links {
+		targetPath, resolved := s.resolveLink(ctx, relPath, link)
+		_, err := s.db.Exec(ctx,
+			`INSERT INTO links (source_file_id, target, target_path, alias, anchor, kind, resolved)
+			 VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''), $6, $7)`,
+			fileID, link.Target, targetPath, link.Alias, link.Anchor, link.Kind, resolved,
+		)
+		if err != nil {
+			return fmt.Errorf("insert link: %w", err)
+		}
+	}
+	return nil
+}
+
+// resolvePendingLinks retries the unresolved links that could point at the
+// file just indexed: same file name with or without extension, or one of its
+// aliases
+func (s *Service) resolvePendingLinks(ctx context.Context, relPath string) error {
+	base := path.Base(relPath)
+	rows, err := s.db.Query(ctx,
+		`SELECT l.id, l.target, COALESCE(l.anchor, ''), l.kind, f.path
+		 FROM links l
+		 JOIN files f ON f.id = l.source_file_id
+		 WHERE NOT l.resolved
+		   AND (lower(regexp_replace(l.target, '^.*/', '')) IN (lower($1), lower($2))
+		        OR EXISTS (SELECT 1 FROM files t WHERE t.path = $3 AND t.metadata->'aliases' ? l.target))`,
+		base, strings.TrimSuffix(base, path.Ext(base)), relPath,
+	)
+	if err != nil {
+		return fmt.Errorf("query pending links: %w", err)
+	}
+	defer rows.Close()
+
+	type pending struct {
+		id         string
+		link       Link
+		sourcePath string
+	}
+	var todo []pending
+	for rows.Next() {
+		var p pending
+		if err := rows.Scan(&p.id, &p.link.Target, &p.link.Anchor, &p.link.Kind, &p.sourcePath); err == nil {
+			todo = append(todo, p)
+		}
+	}
+	rows.Close()
+
+	for _, p := range todo {
+		targetPath, ok := s.resolveLink(ctx, p.sourcePath, p.link)
+		if !ok {
+			continue
+		}
+		_, err := s.db.Exec(ctx,
+			`UPDATE links SET target_path = $1, resolved = TRUE WHERE id = $2`,
+			targetPath, p.id,
+		)
+		if err != nil {
+			return fmt.Errorf("resolve link: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *Service) deleteFile(ctx context.Context, fileID, relPath string) error {
+	rows, err := s.db.Query(ctx, `SELECT id FROM chunks WHERE file_id = $1`, fileID)
+	if err != nil {
+		return fmt.Errorf("query chunks: %w", err)
+	}
+	var chunkIDs []string
+	for rows.Next() {
+		var id string
+		if scanErr := rows.Scan(&id); scanErr == nil {
+			chunkIDs = append(chunkIDs, id)
+		}
+	}
+	rows.Close()
+
+	for _, id := range chunkIDs {
+		if err := s.qdrant.Delete(ctx, id); err != nil {
+			return fmt.Errorf("qdrant delete: %w", err)
+		}
+		_, _ = s.db.Exec(ctx, `DELETE FROM embeddings WHERE chunk_id = $1`, id)
+	}
+
+	_, err = s.db.Exec(ctx,
+		`UPDATE links SET target_path = NULL, resolved = FALSE WHERE target_path = $1`, relPath,
+	)
+	if err != nil {
+		return fmt.Errorf("unresolve links: %w", err)
+	}
+
+	if err := s.deleteFileSummary(ctx, fileID, relPath); err != nil {
+		return err
+	}
+
+	_, _ = s.db.Exec(ctx, `DELETE FROM chunks WHERE file_id = $1`, fileID)
+	_, _ = s.db.Exec(ctx, `UPDATE jobs SET file_id = NULL WHERE file_id = $1`, fileID)
+
+	_, err = s.db.Exec(ctx, `DELETE FROM files WHERE id = $1`, fileID)
+	return err
+}
+
+func (s *Service) removeFile(ctx context.Context, relPath string) error {
+	var fileID string
+	err := s.db.QueryRow(ctx,
+		`SELECT id FROM files WHERE path = $1`, relPath,
+	).Scan(&fileID)
+	if err != nil {
+		return ErrNotFound
+	}
+	return s.deleteFile(ctx, fileID, relPath)
+}
@@ -0,0 +1,322 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrDestinationExists  = errors.New("destination already exists")
+	ErrIgnoredDestination = errors.New("destination is in a directory that is not indexed")
+)
+
+type MoveResult struct {
+	JobID          string   `json:"job_id"`
+	Source         string   `json:"source"`
+	Destination    string   `json:"destination"`
+	RewrittenFiles []string `json:"rewritten_files,omitempty"`
+}
+
+func (s *Service) MoveFile(ctx context.Context, source, destination string, rewriteLinks bool) (*MoveResult, error) {
+	source = filepath.ToSlash(filepath.Clean(source))
+	destination = filepath.ToSlash(filepath.Clean(destination))
+	if path.Ext(destination) == "" {
+		destination += path.Ext(source)
+	}
+	if !supportedFile(destination) {
+		return nil, fmt.Errorf("unsupported file type: %s", path.Ext(destination))
+	}
+	for _, dir := range strings.Split(path.Dir(destination), "/") {
+		if ignoredDir(dir) {
+			return nil, ErrIgnoredDestination
+		}
+	}
+
+	absSource, err := sanitizePath(s.cfg.VaultRoot, source)
+	if err != nil {
+		return nil, err
+	}
+	absDest, err := sanitizePath(s.cfg.VaultRoot, destination)
+	if err != nil {
+		return nil, err
+	}
+
+	if source == destination {
+		return nil, ErrDestinationExists
+	}
+	first, second := min(source, destination), max(source, destination)
+	unlockFirst := s.lockPath(first)
+	defer unlockFirst()
+	unlockSecond := s.lockPath(second)
+	defer unlockSecond()
+
+	var fileID string
+	if err := s.db.QueryRow(ctx,
+		`SELECT id FROM files WHERE path = $1`, source,
+	).Scan(&fileID); err != nil {
+		return nil, ErrNotFound
+	}
+
+	var taken int
+	_ = s.db.QueryRow(ctx, `SELECT COUNT(*) FROM files WHERE path = $1`, destination).Scan(&taken)
+	if _, statErr := os.Stat(absDest); taken > 0 || statErr == nil {
+		return nil, ErrDestinationExists
+	}
+
+	jobID := uuid.New().String()
+	_, err = s.db.Exec(ctx, `INSERT INTO jobs (id, status) VALUES ($1, 'running')`, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("create job: %w", err)
+	}
+
+	result := &MoveResult{JobID: jobID, Source: source, Destination: destination}
+	fail := func(err error) (*MoveResult, error) {
+		_, _ = s.db.Exec(ctx,
+			`UPDATE jobs SET status = 'error', error = $1, updated_at = NOW() WHERE id = $2`,
+			err.Error(), jobID,
+		)
+		return nil, err
+	}
+
+	var backlinks []backlink
+	if rewriteLinks {
+		backlinks, err = s.backlinks(ctx, source)
+		if err != nil {
+			return fail(err)
+		}
+	}
+
+	// the path changes in Postgres are committed only once the file is
+	// renamed and the Qdrant points follow, and each step is undone if a
+	// later one fails
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fail(fmt.Errorf("begin move: %w", err))
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE files SET path = $1 WHERE id = $2`, destination, fileID,
+	); err != nil {
+		return fail(fmt.Errorf("update file path: %w", err))
+	}
+	if _, err := tx.Exec(ctx,
+		`UPDATE file_versions SET path = $1 WHERE file_id = $2`, destination, fileID,
+	); err != nil {
+		return fail(fmt.Errorf("update version paths: %w", err))
+	}
+	if _, err := tx.Exec(ctx,
+		`UPDATE links SET target_path = $1 WHERE target_path = $2`, destination, source,
+	); err != nil {
+		return fail(fmt.Errorf("update links: %w", err))
+	}
+
+	rows, err := tx.Query(ctx, `SELECT id FROM chunks WHERE file_id = $1`, fileID)
+	if err != nil {
+		return fail(fmt.Errorf("query chunks: %w", err))
+	}
+	pointIDs, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return fail(fmt.Errorf("query chunks: %w", err))
+	}
+	summaryID, err := moveFileSummary(ctx, tx, fileID, destination)
+	if err != nil {
+		return fail(err)
+	}
+	if summaryID != "" {
+		pointIDs = append(pointIDs, summaryID)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(absDest), 0755); err != nil {
+		return fail(fmt.Errorf("create dir: %w", err))
+	}
+	if err := os.Rename(absSource, absDest); err != nil {
+		return fail(fmt.Errorf("move file: %w", err))
+	}
+	undo := func() {
+		if len(pointIDs) > 0 {
+			_ = s.qdrant.SetPayload(ctx, pointIDs, map[string]any{"file_path": source})
+		}
+		_ = os.Rename(absDest, absSource)
+	}
+
+	if len(pointIDs) > 0 {
+		if err := s.qdrant.SetPayload(ctx, pointIDs, map[string]any{"file_path": destination}); err != nil {
+			undo()
+			return fail(fmt.Errorf("qdrant set payload: %w", err))
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		undo()
+		return fail(fmt.Errorf("commit move: %w", err))
+	}
+	if summaryID != "" {
+		s.markFolder(path.Dir(source))
+		s.markFolder(path.Dir(destination))
+	}
+
+	if strings.EqualFold(path.Ext(destination), ".md") {
+		content, err := os.ReadFile(absDest)
+		if err != nil {
+			return fail(fmt.Errorf("read file: %w", err))
+		}
+		_, body := parseFrontMatter(string(content))
+		if err := s.updateLinks(ctx, fileID, destination, parseLinks(body)); err != nil {
+			return fail(err)
+		}
+	}
+
+	for _, bl := range groupBacklinks(backlinks) {
+		changed, err := s.rewriteLinks(ctx, bl.sourcePath, bl.targets, destination)
+		if err != nil {
+			return fail(err)
+		}
+		if changed {
+			result.RewrittenFiles = append(result.RewrittenFiles, bl.sourcePath)
+		}
+	}
+
+	if err := s.resolvePendingLinks(ctx, destination); err != nil {
+		return fail(err)
+	}
+
+	_, _ = s.db.Exec(ctx,
+		`UPDATE jobs SET status = 'done', updated_at = NOW() WHERE id = $1`, jobID,
+	)
+	return result, nil
+}
+
+type backlink struct {
+	sourcePath string
+	target     string
+	kind       string
+}
+
+type linkRewrite struct {
+	sourcePath string
+	targets    map[string]bool
+}
+
+func (s *Service) backlinks(ctx context.Context, targetPath string) ([]backlink, error) {
+	rows, err := s.db.Query(ctx,
+		`SELECT f.path, l.target, l.kind
+		 FROM links l
+		 JOIN files f ON f.id = l.source_file_id
+		 WHERE l.target_path = $1 AND f.path <> $1`,
+		targetPath,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query backlinks: %w", err)
+	}
+	defer rows.Close()
+
+	var out []backlink
+	for rows.Next() {
+		var b backlink
+		if err := rows.Scan(&b.sourcePath, &b.target, &b.kind); err == nil {
+			out = append(out, b)
+		}
+	}
+	return out, rows.Err()
+}
+
+func groupBacklinks(backlinks []backlink) []linkRewrite {
+	var out []linkRewrite
+	index := make(map[string]int)
+	for _, b := range backlinks {
+		i, ok := index[b.sourcePath]
+		if !ok {
+			i = len(out)
+			index[b.sourcePath] = i
+			out = append(out, linkRewrite{sourcePath: b.sourcePath, targets: make(map[string]bool)})
+		}
+		out[i].targets[b.kind+"\x00"+b.target] = true
+	}
+	return out
+}
+
+func (s *Service) rewriteLinks(ctx context.Context, sourcePath string, targets map[string]bool, destination string) (bool, error) {
+	unlock := s.lockPath(sourcePath)
+	defer unlock()
+
+	absPath := filepath.Join(s.cfg.VaultRoot, filepath.FromSlash(sourcePath))
+	data, err := os.ReadFile(absPath)
+	if err != nil {
+		return false, fmt.Errorf("read %s: %w", sourcePath, err)
+	}
+
+	linked := func(kind, target string) bool {
+		return targets[kind+"\x00"+target]
+	}
+
+	content := wikiLink.ReplaceAllStringFunc(string(data), func(m string) string {
+		sub := wikiLink.FindStringSubmatch(m)
+		target := strings.TrimSpace(sub[1])
+		if !linked("wikilink", target) {
+			return m
+		}
+		return strings.Replace(m, sub[1], wikiTarget(target, destination), 1)
+	})
+	content = markdownLink.ReplaceAllStringFunc(content, func(m string) string {
+		sub := markdownLink.FindStringSubmatch(m)
+		target, _, _ := strings.Cut(sub[2], "#")
+		if decoded, err := url.PathUnescape(target); err == nil {
+			target = decoded
+		}
+		if !linked("markdown", target) {
+			return m
+		}
+		rel, err := filepath.Rel(path.Dir(sourcePath), destination)
+		if err != nil {
+			return m
+		}
+		rel = (&url.URL{Path: filepath.ToSlash(rel)}).EscapedPath()
+		rawTarget, _, _ := strings.Cut(sub[2], "#")
+		return strings.Replace(m, rawTarget, rel, 1)
+	})
+
+	if content == string(data) {
+		return false, nil
+	}
+	if err := s.snapshotExisting(ctx, sourcePath, data); err != nil {
+		return false, err
+	}
+	if err := writeFileAtomic(absPath, []byte(content), 0644); err != nil {
+		return false, fmt.Errorf("write %s: %w", sourcePath, err)
+	}
+	_, err = s.reindexFile(ctx, sourcePath, []byte(content))
+	return true, err
+}
+
+func wikiTarget(old, destination string) string {
+	newTarget := strings.TrimSuffix(destination, ".md")
+	if !strings.Contains(old, "/") {
+		newTarget = path.Base(newTarget)
+	}
+	if strings.HasSuffix(strings.ToLower(old), ".md") {
+		newTarget += ".md"
+	}
+	return newTarget
+}
+
+func (s *Service) reindexFile(ctx context.Context, relPath string, data []byte) (string, error) {
+	action, fileID, err := s.upsertFile(ctx, FileEntry{
+		Path:         relPath,
+		Hash:         fmt.Sprintf("%x", sha256sum(data)),
+		LastModified: time.Now(),
+	})
+	if err != nil || action == "skip" {
+		return action, err
+	}
+	return action, s.indexFile(ctx, fileID, relPath, filepath.Join(s.cfg.VaultRoot, filepath.FromSlash(relPath)))
+}
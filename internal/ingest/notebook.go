@@ -0,0 +1,147 @@
+package ingest
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type notebook struct {
+	Metadata struct {
+		Kernelspec struct {
+			Language string `json:"language"`
+		} `json:"kernelspec"`
+		LanguageInfo struct {
+			Name string `json:"name"`
+		} `json:"language_info"`
+	} `json:"metadata"`
+	Cells []notebookCell `json:"cells"`
+}
+
+type notebookCell struct {
+	CellType string           `json:"cell_type"`
+	Source   multilineText    `json:"source"`
+	Outputs  []notebookOutput `json:"outputs"`
+}
+
+type notebookOutput struct {
+	OutputType string                   `json:"output_type"`
+	Text       multilineText            `json:"text"`
+	Data       map[string]multilineText `json:"data"`
+	EName      string                   `json:"ename"`
+	EValue     string                   `json:"evalue"`
+}
+
+type multilineText string
+
+func (t *multilineText) UnmarshalJSON(data []byte) error {
+	var lines []string
+	if err := json.Unmarshal(data, &lines); err == nil {
+		*t = multilineText(strings.Join(lines, ""))
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil
+	}
+	*t = multilineText(s)
+	return nil
+}
+
+func parseNotebook(data []byte) (*notebook, error) {
+	var nb notebook
+	if err := json.Unmarshal(data, &nb); err != nil {
+		return nil, fmt.Errorf("parse notebook: %w", err)
+	}
+	return &nb, nil
+}
+
+func (nb *notebook) language() string {
+	if nb.Metadata.LanguageInfo.Name != "" {
+		return nb.Metadata.LanguageInfo.Name
+	}
+	return nb.Metadata.Kernelspec.Language
+}
+
+func (c notebookCell) outputText() string {
+	var parts []string
+	for _, o := range c.Outputs {
+		switch o.OutputType {
+		case "stream":
+			parts = append(parts, string(o.Text))
+		case "execute_result", "display_data":
+			if text, ok := o.Data["text/plain"]; ok {
+				parts = append(parts, string(text))
+			}
+		case "error":
+			parts = append(parts, o.EName+": "+o.EValue)
+		}
+	}
+	return strings.TrimSpace(strings.Join(parts, "\n"))
+}
+
+func chunkNotebook(filePath string, data []byte, opts chunkOptions) ([]Chunk, error) {
+	nb, err := parseNotebook(data)
+	if err != nil {
+		return nil, err
+	}
+	language := nb.language()
+
+	var chunks []Chunk
+	for i, cell := range nb.Cells {
+		source := strings.TrimSpace(string(cell.Source))
+		if source == "" {
+			continue
+		}
+
+		var cellChunks []Chunk
+		switch cell.CellType {
+		case "markdown":
+			cellChunks = chunkParagraphs(filePath, source, fmt.Sprintf("cell %d, ", i+1))
+		case "code":
+			text := source
+			if opts.NotebookOutputs {
+				if out := cell.outputText(); out != "" {
+					text += "\n\nOutput:\n" + out
+				}
+			}
+			cellChunks = windowChunks(filePath, text, fmt.Sprintf("cell %d (code)", i+1))
+		default:
+			continue
+		}
+
+		for j := range cellChunks {
+			cellChunks[j].Payload = map[string]any{
+				"cell_index": i + 1,
+				"cell_type":  cell.CellType,
+			}
+			if cell.CellType == "code" && language != "" {
+				cellChunks[j].Payload["language"] = language
+			}
+		}
+		chunks = append(chunks, cellChunks...)
+	}
+	return chunks, nil
+}
+
+func notebookText(data []byte) (string, error) {
+	nb, err := parseNotebook(data)
+	if err != nil {
+		return "", err
+	}
+
+	var parts []string
+	for _, cell := range nb.Cells {
+		source := strings.TrimSpace(string(cell.Source))
+		switch cell.CellType {
+		case "markdown":
+			parts = append(parts, source)
+		case "code":
+			parts = append(parts, "```"+nb.language()+"\n"+source+"\n```")
+			if out := cell.outputText(); out != "" {
+				parts = append(parts, "```\n"+out+"\n```")
+			}
+		}
+	}
+	return strings.Join(parts, "\n\n"), nil
+}
@@ -0,0 +1,193 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	templatesDir  = "_templates"
+	journalDir    = "journal"
+	dailyTemplate = "daily"
+)
+
+var (
+	ErrTemplateNotFound = errors.New("template not found")
+	ErrNoteExists       = errors.New("note already exists")
+	ErrInvalidTitle     = errors.New("title has no usable characters")
+)
+
+var (
+	templateVar  = regexp.MustCompile(`\{\{\s*([\w.-]+)\s*\}\}`)
+	invalidTitle = regexp.MustCompile(`[/\\:*?"<>|#^\[\]]`)
+)
+
+type NoteRequest struct {
+	Template string
+	Title    string
+	Path     string
+	Vars     map[string]string
+}
+
+type NoteResult struct {
+	JobID    string `json:"job_id"`
+	Path     string `json:"path"`
+	Created  bool   `json:"created"`
+	FileHash string `json:"file_hash"`
+}
+
+func (s *Service) readTemplate(name string) (string, error) {
+	relPath := path.Join(templatesDir, strings.TrimSuffix(name, ".md")+".md")
+	if !strings.HasPrefix(relPath, templatesDir+"/") {
+		return "", fmt.Errorf("%w: %s", ErrTemplateNotFound, name)
+	}
+	absPath, err := sanitizePath(s.cfg.VaultRoot, relPath)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(absPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("%w: %s", ErrTemplateNotFound, name)
+	}
+	if err != nil {
+		return "", fmt.Errorf("read template: %w", err)
+	}
+	return string(data), nil
+}
+
+func renderTemplate(tmpl, title string, now time.Time, vars map[string]string) string {
+	values := map[string]string{
+		"title":    title,
+		"date":     now.Format("2006-01-02"),
+		"time":     now.Format("15:04"),
+		"datetime": now.Format("2006-01-02 15:04"),
+	}
+	for k, v := range vars {
+		values[k] = v
+	}
+
+	return templateVar.ReplaceAllStringFunc(tmpl, func(m string) string {
+		if v, ok := values[templateVar.FindStringSubmatch(m)[1]]; ok {
+			return v
+		}
+		return m
+	})
+}
+
+func (s *Service) CreateNote(ctx context.Context, req NoteRequest) (*NoteResult, error) {
+	name := strings.TrimSpace(invalidTitle.ReplaceAllString(req.Title, ""))
+	if name == "" {
+		return nil, ErrInvalidTitle
+	}
+	dir := req.Path
+	if dir == "" {
+		dir = "api-notes"
+	}
+
+	tmpl, err := s.readTemplate(req.Template)
+	if err != nil {
+		return nil, err
+	}
+	content := renderTemplate(tmpl, req.Title, time.Now(), req.Vars)
+
+	return s.writeNote(ctx, path.Join(filepath.ToSlash(dir), name+".md"), func(existing []byte, exists bool) ([]byte, error) {
+		if exists {
+			return nil, ErrNoteExists
+		}
+		return []byte(content), nil
+	})
+}
+
+func (s *Service) AppendDaily(ctx context.Context, day time.Time, text string) (*NoteResult, error) {
+	date := day.Format("2006-01-02")
+
+	return s.writeNote(ctx, path.Join(journalDir, date+".md"), func(existing []byte, exists bool) ([]byte, error) {
+		content := string(existing)
+		if !exists {
+			tmpl, err := s.readTemplate(dailyTemplate)
+			if errors.Is(err, ErrTemplateNotFound) {
+				tmpl, err = "# {{date}}\n", nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			content = renderTemplate(tmpl, date, day, nil)
+		}
+		if text = strings.Trim(text, "\n"); text != "" {
+			content = strings.TrimRight(content, "\n") + "\n\n" + text + "\n"
+		}
+		return []byte(content), nil
+	})
+}
+
+func (s *Service) writeNote(ctx context.Context, relPath string, build func(existing []byte, exists bool) ([]byte, error)) (*NoteResult, error) {
+	absPath, err := sanitizePath(s.cfg.VaultRoot, relPath)
+	if err != nil {
+		return nil, err
+	}
+
+	unlock := s.lockPath(relPath)
+	defer unlock()
+
+	existing, err := os.ReadFile(absPath)
+	exists := err == nil
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+
+	content, err := build(existing, exists)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		if err := s.snapshotExisting(ctx, relPath, existing); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(absPath), 0755); err != nil {
+		return nil, fmt.Errorf("create dir: %w", err)
+	}
+
+	jobID := uuid.New().String()
+	_, err = s.db.Exec(ctx, `INSERT INTO jobs (id, status) VALUES ($1, 'running')`, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("create job: %w", err)
+	}
+
+	if err := writeFileAtomic(absPath, content, 0644); err != nil {
+		err = fmt.Errorf("write file: %w", err)
+		_, _ = s.db.Exec(ctx,
+			`UPDATE jobs SET status = 'error', error = $1, updated_at = NOW() WHERE id = $2`,
+			err.Error(), jobID,
+		)
+		return nil, err
+	}
+
+	if _, err := s.reindexFile(ctx, relPath, content); err != nil {
+		_, _ = s.db.Exec(ctx,
+			`UPDATE jobs SET status = 'error', error = $1, updated_at = NOW() WHERE id = $2`,
+			err.Error(), jobID,
+		)
+		return nil, err
+	}
+
+	_, _ = s.db.Exec(ctx,
+		`UPDATE jobs SET status = 'done', updated_at = NOW() WHERE id = $1`, jobID,
+	)
+	return &NoteResult{
+		JobID:    jobID,
+		Path:     relPath,
+		Created:  !exists,
+		FileHash: fileETag(content),
+	}, nil
+}
@@ -0,0 +1,158 @@
+package ingest
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrHeadingNotFound = errors.New("heading not found")
+	ErrMatchCount      = errors.New("unexpected number of matches")
+)
+
+type Edit struct {
+	Op            string
+	Heading       string
+	Text          string
+	Find          string
+	Replace       string
+	ExpectedCount int
+}
+
+var EditOps = map[string]bool{
+	"overwrite":            true,
+	"append":               true,
+	"prepend":              true,
+	"insert_under_heading": true,
+	"replace_section":      true,
+	"replace_text":         true,
+}
+
+var (
+	headingLine = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	listItem    = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)])\s`)
+)
+
+type section struct {
+	start, bodyStart, bodyEnd, end int
+}
+
+func findSection(lines []string, heading string) (section, bool) {
+	wantLevel := 0
+	if m := headingLine.FindStringSubmatch(strings.TrimSpace(heading)); m != nil {
+		wantLevel = len(m[1])
+		heading = m[2]
+	}
+	heading = strings.TrimSpace(heading)
+
+	inFence := false
+	level := 0
+	sec := section{start: -1}
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		m := headingLine.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+
+		if sec.start >= 0 {
+			if sec.bodyEnd < 0 {
+				sec.bodyEnd = i
+			}
+			if len(m[1]) <= level {
+				sec.end = i
+				return sec, true
+			}
+			continue
+		}
+		if (wantLevel == 0 || len(m[1]) == wantLevel) && strings.EqualFold(m[2], heading) {
+			level = len(m[1])
+			sec = section{start: i, bodyStart: i + 1, bodyEnd: -1}
+		}
+	}
+	if sec.start < 0 {
+		return sec, false
+	}
+	sec.end = len(lines)
+	if sec.bodyEnd < 0 {
+		sec.bodyEnd = sec.end
+	}
+	return sec, true
+}
+
+// applyEdit writes LF line endings whatever the file or the edit used, so
+// CRLF notes do not end up mixed
+func applyEdit(raw string, edit Edit) (string, error) {
+	existing := normalizeNewlines(raw)
+	edit.Text = normalizeNewlines(edit.Text)
+	edit.Find = normalizeNewlines(edit.Find)
+	edit.Replace = normalizeNewlines(edit.Replace)
+
+	switch edit.Op {
+	case "overwrite":
+		return edit.Text, nil
+
+	case "append":
+		return existing + "\n" + edit.Text, nil
+
+	case "prepend":
+		_, body := splitFrontMatter(existing)
+		head := existing[:len(existing)-len(body)]
+		return head + strings.TrimRight(edit.Text, "\n") + "\n\n" + body, nil
+
+	case "insert_under_heading", "replace_section":
+		lines := strings.Split(existing, "\n")
+		sec, ok := findSection(lines, edit.Heading)
+		if !ok {
+			return "", fmt.Errorf("%w: %s", ErrHeadingNotFound, edit.Heading)
+		}
+
+		text := strings.Split(strings.Trim(edit.Text, "\n"), "\n")
+
+		// Inserts land after the heading's own paragraphs, before any subsections.
+		end, rest := sec.bodyEnd, sec.bodyEnd
+		if edit.Op == "replace_section" {
+			end, rest = sec.bodyStart, sec.end
+		}
+		for end > sec.bodyStart && strings.TrimSpace(lines[end-1]) == "" {
+			end--
+		}
+
+		out := append([]string{}, lines[:end]...)
+		if end == sec.bodyStart || !listItem.MatchString(text[0]) || !listItem.MatchString(lines[end-1]) {
+			out = append(out, "")
+		}
+		out = append(out, text...)
+		if rest < len(lines) {
+			out = append(out, "")
+			out = append(out, lines[rest:]...)
+		} else if strings.HasSuffix(existing, "\n") {
+			out = append(out, "")
+		}
+		return strings.Join(out, "\n"), nil
+
+	case "replace_text":
+		if edit.Find == "" {
+			return "", errors.New("find is required")
+		}
+		count := strings.Count(existing, edit.Find)
+		if count == 0 || (edit.ExpectedCount > 0 && count != edit.ExpectedCount) {
+			return "", fmt.Errorf("%w: found %d, expected %d", ErrMatchCount, count, max(edit.ExpectedCount, 1))
+		}
+		return strings.ReplaceAll(existing, edit.Find, edit.Replace), nil
+	}
+
+	return "", fmt.Errorf("unknown op %q", edit.Op)
+}
+
+func normalizeNewlines(s string) string {
+	return strings.ReplaceAll(s, "\r\n", "\n")
+}
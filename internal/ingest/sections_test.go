@@ -0,0 +1,26 @@
+package ingest
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApplyEditLineEndings(t *testing.T) {
+	raw := "# Notes\r\n\r\n- one\r\n"
+	for _, edit := range []Edit{
+		{Op: "overwrite", Text: "# Notes\r\n\r\n- two\r\n"},
+		{Op: "append", Text: "- two\r\n"},
+		{Op: "prepend", Text: "Intro\r\n"},
+		{Op: "insert_under_heading", Heading: "Notes", Text: "- two\r\n"},
+		{Op: "replace_section", Heading: "Notes", Text: "- two\r\n"},
+		{Op: "replace_text", Find: "- one\r\n", Replace: "- two\r\n- three\r\n"},
+	} {
+		out, err := applyEdit(raw, edit)
+		if err != nil {
+			t.Fatalf("%s: %v", edit.Op, err)
+		}
+		if strings.Contains(out, "\r") {
+			t.Errorf("%s: mixed line endings in %q", edit.Op, out)
+		}
+	}
+}
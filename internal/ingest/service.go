@@ -5,8 +5,10 @@ import (
 	"crypto/sha256"
 	"errors"
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,6 +19,8 @@ type IngestResult struct {
 	NewFiles     int    `json:"new_files"`
 	UpdatedFiles int    `json:"updated_files"`
 	Skipped      int    `json:"skipped"`
+	Failed       int    `json:"failed"`
+	FileHash     string `json:"file_hash,omitempty"`
 }
 
 func (s *Service) IngestPath(ctx context.Context, relPath string) (*IngestResult, error) {
@@ -48,25 +52,28 @@ func (s *Service) IngestPath(ctx context.Context, relPath string) (*IngestResult
 		switch action {
 		case "skip":
 			result.Skipped++
-		case "new":
-			result.NewFiles++
-			absPath := filepath.Join(s.cfg.VaultRoot, entry.Path)
-			if err := s.indexFile(ctx, fileID, entry.Path, absPath); err != nil {
-				_, _ = s.db.Exec(ctx,
-					`UPDATE jobs SET status = 'error', error = $1, updated_at = NOW() WHERE id = $2`,
-					err.Error(), jobID,
-				)
-				return nil, err
+			if s.cfg.Summaries {
+				if err := s.summarizeFile(ctx, fileID, filepath.ToSlash(entry.Path), s.storedTexts(ctx, fileID)); err != nil {
+					log.Printf("file summary %s: %v", entry.Path, err)
+				}
 			}
-		case "updated":
-			result.UpdatedFiles++
+		case "new", "updated":
 			absPath := filepath.Join(s.cfg.VaultRoot, entry.Path)
 			if err := s.indexFile(ctx, fileID, entry.Path, absPath); err != nil {
+				// one unreadable file must not stop the rest of the run; its
+				// file_hash is left stale so the next run retries it
+				log.Printf("index %s: %v", entry.Path, err)
 				_, _ = s.db.Exec(ctx,
-					`UPDATE jobs SET status = 'error', error = $1, updated_at = NOW() WHERE id = $2`,
-					err.Error(), jobID,
+					`UPDATE files SET status = 'error', error = $1 WHERE id = $2`,
+					err.Error(), fileID,
 				)
-				return nil, err
+				result.Failed++
+				continue
+			}
+			if action == "new" {
+				result.NewFiles++
+			} else {
+				result.UpdatedFiles++
 			}
 		}
 	}
@@ -93,12 +100,26 @@ func (s *Service) upsertFile(ctx context.Context, entry FileEntry) (string, stri
 	slashPath := filepath.ToSlash(entry.Path)
 
 	if existingID == "" {
+		// a file recreated at the path of a deleted one continues its history
 		var newID string
+		// a file already on disk was created no later than its mtime
+		created := time.Now()
+		if entry.LastModified.Before(created) {
+			created = entry.LastModified
+		}
 		err := s.db.QueryRow(ctx,
-			`INSERT INTO files (path, file_hash, last_modified, status)
-			 VALUES ($1, $2, $3, 'pending') RETURNING id`,
-			slashPath, entry.Hash, entry.LastModified,
+			`INSERT INTO files (path, file_hash, last_modified, created_at, status, version)
+			 VALUES ($1, '', $2, $3, 'pending',
+			         COALESCE((SELECT MAX(version) FROM file_versions WHERE file_id IS NULL AND path = $1), 0) + 1)
+			 RETURNING id`,
+			slashPath, entry.LastModified, created,
 		).Scan(&newID)
+		if err != nil {
+			return "", "", err
+		}
+		_, err = s.db.Exec(ctx,
+			`UPDATE file_versions SET file_id = $1 WHERE file_id IS NULL AND path = $2`, newID, slashPath,
+		)
 		return "new", newID, err
 	}
 
@@ -106,65 +127,165 @@ func (s *Service) upsertFile(ctx context.Context, entry FileEntry) (string, stri
 		return "skip", existingID, nil
 	}
 
+	// file_hash is only written once indexFile succeeds; a retry of a failed
+	// file keeps its version number
 	_, err = s.db.Exec(ctx,
-		`UPDATE files SET file_hash = $1, version = version + 1,
-		 last_modified = $2, status = 'pending' WHERE id = $3`,
-		entry.Hash, time.Now(), existingID,
+		`UPDATE files SET version = version + CASE WHEN status = 'error' THEN 0 ELSE 1 END,
+		 last_modified = $1, status = 'pending' WHERE id = $2`,
+		time.Now(), existingID,
 	)
 	return "updated", existingID, err
 }
 
+func chunkPayload(relPath string, chunk Chunk, metaPayload map[string]any) map[string]any {
+	payload := map[string]any{
+		"chunk_id":  chunk.ID,
+		"file_path": relPath,
+		"position":  chunk.Position,
+	}
+	for k, v := range chunk.Payload {
+		payload[k] = v
+	}
+	for k, v := range metaPayload {
+		payload[k] = v
+	}
+	return payload
+}
+
 func (s *Service) indexFile(ctx context.Context, fileID, relPath, absPath string) error {
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return fmt.Errorf("stat file %s: %w", absPath, err)
+	}
 	content, err := os.ReadFile(absPath)
 	if err != nil {
 		return fmt.Errorf("read file %s: %w", absPath, err)
 	}
 
-	chunks := ChunkText(filepath.ToSlash(relPath), string(content))
+	if err := s.snapshot(ctx, fileID, content); err != nil {
+		return err
+	}
 
-	newIDs := make(map[string]struct{}, len(chunks))
-	for _, c := range chunks {
-		newIDs[c.ID] = struct{}{}
+	chunks, err := chunkFile(filepath.ToSlash(relPath), content, chunkOptions{
+		NotebookOutputs: s.cfg.NotebookOutputs,
+	})
+	if err != nil {
+		return err
+	}
+
+	metadata := fileMetadata(relPath, content)
+	var metaPayload map[string]any
+	if metadata != nil {
+		if _, err := s.db.Exec(ctx,
+			`UPDATE files SET metadata = $1 WHERE id = $2`, metadata, fileID,
+		); err != nil {
+			return fmt.Errorf("update metadata: %w", err)
+		}
+
+		metaPayload = make(map[string]any, len(s.cfg.FrontMatterKeys))
+		for _, key := range s.cfg.FrontMatterKeys {
+			metaPayload[key] = metadata[key]
+		}
 	}
 
 	oldRows, err := s.db.Query(ctx,
-		`SELECT id FROM chunks WHERE file_id = $1`, fileID,
+		`SELECT id, chunk_text FROM chunks WHERE file_id = $1`, fileID,
 	)
 	if err != nil {
 		return fmt.Errorf("query old chunks: %w", err)
 	}
 	defer oldRows.Close()
 
-	var toDelete []string
+	oldByText := make(map[string]string)
+	var oldIDs []string
 	for oldRows.Next() {
-		var id string
-		if scanErr := oldRows.Scan(&id); scanErr == nil {
-			if _, exists := newIDs[id]; !exists {
-				toDelete = append(toDelete, id)
-			}
+		var id, text string
+		if scanErr := oldRows.Scan(&id, &text); scanErr == nil {
+			oldIDs = append(oldIDs, id)
+			oldByText[text] = id
 		}
 	}
 	oldRows.Close()
 
+	// chunk IDs include the file path, so after a move the same text gets a
+	// new ID; reuse the existing chunk instead of embedding it again
+	var fresh []string
+	for i, c := range chunks {
+		if id, ok := oldByText[c.Text]; ok {
+			chunks[i].ID = id
+			delete(oldByText, c.Text)
+			continue
+		}
+		fresh = append(fresh, c.ID)
+	}
+
+	// a file moved away from this path keeps the IDs hashed from it, so the
+	// same text here would collide with that file's chunk; key ours by file ID
+	if len(fresh) > 0 {
+		taken := make(map[string]bool)
+		rows, err := s.db.Query(ctx,
+			`SELECT id FROM chunks WHERE id = ANY($1) AND file_id <> $2`, fresh, fileID,
+		)
+		if err != nil {
+			return fmt.Errorf("query chunk ids: %w", err)
+		}
+		for rows.Next() {
+			var id string
+			if err := rows.Scan(&id); err == nil {
+				taken[id] = true
+			}
+		}
+		rows.Close()
+		for i, c := range chunks {
+			if taken[c.ID] {
+				chunks[i].ID = chunkID(fileID, c.Text)
+			}
+		}
+	}
+
+	newIDs := make(map[string]struct{}, len(chunks))
+	for _, c := range chunks {
+		newIDs[c.ID] = struct{}{}
+	}
+
+	var toDelete []string
+	for _, id := range oldIDs {
+		if _, exists := newIDs[id]; !exists {
+			toDelete = append(toDelete, id)
+		}
+	}
+
 	for _, id := range toDelete {
 		_ = s.qdrant.Delete(ctx, id)
 		_, _ = s.db.Exec(ctx, `DELETE FROM embeddings WHERE chunk_id = $1`, id)
 		_, _ = s.db.Exec(ctx, `DELETE FROM chunks WHERE id = $1`, id)
 	}
 
-	for _, chunk := range chunks {
+	for i, chunk := range chunks {
 		var existing string
 		err := s.db.QueryRow(ctx,
-			`SELECT id FROM chunks WHERE id = $1`, chunk.ID,
+			`SELECT id FROM chunks WHERE id = $1 AND file_id = $2`, chunk.ID, fileID,
 		).Scan(&existing)
 		if err == nil {
+			_, err = s.db.Exec(ctx,
+				`UPDATE chunks SET chunk_index = $1, position = $2 WHERE id = $3`,
+				i, chunk.Position, chunk.ID,
+			)
+			if err != nil {
+				return fmt.Errorf("update chunk: %w", err)
+			}
+			// line ranges, symbols and timestamps move with the text around
+			// the chunk, so refresh them even though the vector is reused
+			if err := s.qdrant.SetPayload(ctx, []string{chunk.ID}, chunkPayload(relPath, chunk, metaPayload)); err != nil {
+				return fmt.Errorf("qdrant set payload: %w", err)
+			}
 			continue
 		}
 
 		_, err = s.db.Exec(ctx,
-			`INSERT INTO chunks (id, file_id, chunk_text, position)
-			 VALUES ($1, $2, $3, $4) ON CONFLICT (id) DO NOTHING`,
-			chunk.ID, fileID, chunk.Text, chunk.Position,
+			`INSERT INTO chunks (id, file_id, chunk_text, position, chunk_index)
+			 VALUES ($1, $2, $3, $4, $5) ON CONFLICT (id) DO NOTHING`,
+			chunk.ID, fileID, chunk.Text, chunk.Position, i,
 		)
 		if err != nil {
 			return fmt.Errorf("insert chunk: %w", err)
@@ -175,12 +296,7 @@ func (s *Service) indexFile(ctx context.Context, fileID, relPath, absPath string
 			return fmt.Errorf("embed chunk %s: %w", chunk.ID, err)
 		}
 
-		payload := map[string]any{
-			"chunk_id":  chunk.ID,
-			"file_path": relPath,
-			"position":  chunk.Position,
-		}
-		if err := s.qdrant.Upsert(ctx, chunk.ID, vector, payload); err != nil {
+		if err := s.qdrant.Upsert(ctx, chunk.ID, vector, chunkPayload(relPath, chunk, metaPayload)); err != nil {
 			return fmt.Errorf("qdrant upsert: %w", err)
 		}
 
@@ -194,13 +310,37 @@ func (s *Service) indexFile(ctx context.Context, fileID, relPath, absPath string
 		}
 	}
 
+	if strings.EqualFold(filepath.Ext(relPath), ".md") {
+		_, body := parseFrontMatter(string(content))
+		if err := s.updateLinks(ctx, fileID, filepath.ToSlash(relPath), parseLinks(body)); err != nil {
+			return err
+		}
+	}
+	if err := s.resolvePendingLinks(ctx, filepath.ToSlash(relPath)); err != nil {
+		return err
+	}
+
 	_, err = s.db.Exec(ctx,
-		`UPDATE files SET status = 'ready' WHERE id = $1`, fileID,
+		`UPDATE files SET file_hash = $1, size = $2, mtime = $3, status = 'ready', error = NULL WHERE id = $4`,
+		fmt.Sprintf("%x", sha256sum(content)), info.Size(), info.ModTime().UTC(), fileID,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+
+	if s.cfg.Summaries {
+		if err := s.summarizeFile(ctx, fileID, filepath.ToSlash(relPath), chunkTexts(chunks)); err != nil {
+			log.Printf("file summary %s: %v", relPath, err)
+		}
+	}
+	return nil
 }
 
 func (s *Service) IngestDirect(ctx context.Context, filename, relPath, content string) (*IngestResult, error) {
+	return s.ingestFile(ctx, filename+".md", relPath, []byte(content))
+}
+
+func (s *Service) ingestFile(ctx context.Context, name, relPath string, data []byte) (*IngestResult, error) {
 	if relPath == "" {
 		relPath = "api-notes"
 	}
@@ -214,13 +354,21 @@ func (s *Service) IngestDirect(ctx context.Context, filename, relPath, content s
 		return nil, fmt.Errorf("create dir: %w", err)
 	}
 
-	absFile := filepath.Join(absDir, filename+".md")
-	if err := os.WriteFile(absFile, []byte(content), 0644); err != nil {
+	relFilePath := filepath.ToSlash(filepath.Join(relPath, name))
+	unlock := s.lockPath(relFilePath)
+	defer unlock()
+
+	absFile := filepath.Join(absDir, name)
+	if existing, err := os.ReadFile(absFile); err == nil {
+		if err := s.snapshotExisting(ctx, relFilePath, existing); err != nil {
+			return nil, err
+		}
+	}
+	if err := writeFileAtomic(absFile, data, 0644); err != nil {
 		return nil, fmt.Errorf("write file: %w", err)
 	}
 
-	relFilePath := filepath.ToSlash(filepath.Join(relPath, filename+".md"))
-	hash := fmt.Sprintf("%x", sha256sum([]byte(content)))
+	hash := fmt.Sprintf("%x", sha256sum(data))
 
 	entry := FileEntry{
 		Path:         relFilePath,
@@ -236,7 +384,7 @@ func (s *Service) IngestDirect(ctx context.Context, filename, relPath, content s
 		return nil, fmt.Errorf("create job: %w", err)
 	}
 
-	result := &IngestResult{JobID: jobID}
+	result := &IngestResult{JobID: jobID, FileHash: hash}
 
 	action, fileID, err := s.upsertFile(ctx, entry)
 	if err != nil {
@@ -286,140 +434,125 @@ type MultipleMatchesError struct {
 
 func (e *MultipleMatchesError) Error() string { return ErrMultipleMatches.Error() }
 
-func (s *Service) GetFile(ctx context.Context, filename, format, path string) (content, filePath string, updatedAt time.Time, err error) {
-	ext := "." + format
-	nameWithExt := filename + ext
-
-	var rows []struct {
-		Path         string
-		LastModified time.Time
+func (s *Service) resolveFile(ctx context.Context, filename, format, path string) (string, error) {
+	if format == "" {
+		format = "md"
 	}
+	nameWithExt := filename + "." + format
 
 	if path != "" {
 		relPath := filepath.ToSlash(filepath.Join(path, nameWithExt))
-		var f struct {
-			Path         string
-			LastModified time.Time
-		}
-		dbErr := s.db.QueryRow(ctx,
-			`SELECT path, last_modified FROM files WHERE path = $1`, relPath,
-		).Scan(&f.Path, &f.LastModified)
-		if dbErr != nil {
-			return "", "", time.Time{}, ErrNotFound
-		}
-		rows = append(rows, f)
-	} else {
-		dbRows, dbErr := s.db.Query(ctx,
-			`SELECT path, last_modified FROM files WHERE path LIKE $1
-			 ORDER BY CASE WHEN path LIKE 'api-notes/%' THEN 0 ELSE 1 END`,
-			"%/"+nameWithExt,
-		)
-		if dbErr != nil {
-			return "", "", time.Time{}, dbErr
-		}
-		defer dbRows.Close()
-		for dbRows.Next() {
-			var f struct {
-				Path         string
-				LastModified time.Time
-			}
-			if scanErr := dbRows.Scan(&f.Path, &f.LastModified); scanErr == nil {
-				rows = append(rows, f)
-			}
+		var found string
+		err := s.db.QueryRow(ctx,
+			`SELECT path FROM files WHERE path = $1`, relPath,
+		).Scan(&found)
+		if err != nil {
+			return "", ErrNotFound
 		}
+		return found, nil
 	}
 
-	if len(rows) == 0 {
-		return "", "", time.Time{}, ErrNotFound
+	rows, err := s.db.Query(ctx,
+		`SELECT path FROM files WHERE path LIKE $1
+		 ORDER BY CASE WHEN path LIKE 'api-notes/%' THEN 0 ELSE 1 END`,
+		"%/"+nameWithExt,
+	)
+	if err != nil {
+		return "", err
 	}
-	if len(rows) > 1 {
-		matches := make([]map[string]string, len(rows))
-		for i, r := range rows {
-			matches[i] = map[string]string{
-				"path":      filepath.Dir(r.Path),
-				"full_path": filepath.Join(s.cfg.VaultRoot, r.Path),
-			}
+	defer rows.Close()
+
+	var matches []string
+	for rows.Next() {
+		var p string
+		if scanErr := rows.Scan(&p); scanErr == nil {
+			matches = append(matches, p)
 		}
-		return "", "", time.Time{}, &MultipleMatchesError{Matches: matches}
 	}
 
-	absPath := filepath.Join(s.cfg.VaultRoot, filepath.FromSlash(rows[0].Path))
-	data, readErr := os.ReadFile(absPath)
-	if readErr != nil {
-		return "", "", time.Time{}, readErr
+	switch len(matches) {
+	case 0:
+		return "", ErrNotFound
+	case 1:
+		return matches[0], nil
+	default:
+		result := make([]map[string]string, len(matches))
+		for i, m := range matches {
+			result[i] = map[string]string{
+				"path":      filepath.Dir(m),
+				"full_path": filepath.Join(s.cfg.VaultRoot, m),
+			}
+		}
+		return "", &MultipleMatchesError{Matches: result}
 	}
+}
 
-	return string(data), filepath.Dir(rows[0].Path), rows[0].LastModified, nil
+type File struct {
+	Content   string
+	Path      string
+	UpdatedAt time.Time
+	Metadata  map[string]any
+	ETag      string
 }
 
-func (s *Service) EditFile(ctx context.Context, filename, format, path, content, appendText string) (*IngestResult, error) {
-	if format == "" {
-		format = "md"
+func (s *Service) GetFile(ctx context.Context, filename, format, path string) (*File, error) {
+	relPath, err := s.resolveFile(ctx, filename, format, path)
+	if err != nil {
+		return nil, err
 	}
-	ext := "." + format
-	nameWithExt := filename + ext
 
-	var relFilePath string
+	f := &File{Path: filepath.Dir(relPath)}
+	err = s.db.QueryRow(ctx,
+		`SELECT last_modified, metadata FROM files WHERE path = $1`, relPath,
+	).Scan(&f.UpdatedAt, &f.Metadata)
+	if err != nil {
+		return nil, ErrNotFound
+	}
 
-	if path != "" {
-		relFilePath = filepath.ToSlash(filepath.Join(path, nameWithExt))
-		var count int
-		err := s.db.QueryRow(ctx,
-			`SELECT COUNT(*) FROM files WHERE path = $1`, relFilePath,
-		).Scan(&count)
-		if err != nil || count == 0 {
-			return nil, ErrNotFound
-		}
-	} else {
-		rows, err := s.db.Query(ctx,
-			`SELECT path FROM files WHERE path LIKE $1
-			 ORDER BY CASE WHEN path LIKE 'api-notes/%' THEN 0 ELSE 1 END`,
-			"%/"+nameWithExt,
-		)
-		if err != nil {
-			return nil, err
-		}
-		defer rows.Close()
+	absPath := filepath.Join(s.cfg.VaultRoot, filepath.FromSlash(relPath))
+	data, err := os.ReadFile(absPath)
+	if err != nil {
+		return nil, err
+	}
 
-		var matches []string
-		for rows.Next() {
-			var p string
-			if scanErr := rows.Scan(&p); scanErr == nil {
-				matches = append(matches, p)
-			}
-		}
+	f.Content, err = extractText(relPath, data)
+	if err != nil {
+		return nil, err
+	}
+	f.ETag = fileETag(data)
 
-		switch len(matches) {
-		case 0:
-			return nil, ErrNotFound
-		case 1:
-			relFilePath = matches[0]
-		default:
-			result := make([]map[string]string, len(matches))
-			for i, m := range matches {
-				result[i] = map[string]string{
-					"path":      filepath.Dir(m),
-					"full_path": filepath.Join(s.cfg.VaultRoot, m),
-				}
-			}
-			return nil, &MultipleMatchesError{Matches: result}
-		}
+	return f, nil
+}
+
+func (s *Service) EditFile(ctx context.Context, filename, format, path string, edit Edit, ifMatch string) (*IngestResult, error) {
+	relFilePath, err := s.resolveFile(ctx, filename, format, path)
+	if err != nil {
+		return nil, err
 	}
 
+	unlock := s.lockPath(relFilePath)
+	defer unlock()
+
 	absPath := filepath.Join(s.cfg.VaultRoot, filepath.FromSlash(relFilePath))
 
-	var newContent string
-	if appendText != "" {
-		existing, err := os.ReadFile(absPath)
-		if err != nil {
-			return nil, fmt.Errorf("read file: %w", err)
-		}
-		newContent = string(existing) + "\n" + appendText
-	} else {
-		newContent = content
+	existing, err := os.ReadFile(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+	if !etagMatches(ifMatch, existing) {
+		return nil, ErrPreconditionFailed
 	}
 
-	if err := os.WriteFile(absPath, []byte(newContent), 0644); err != nil {
+	newContent, err := applyEdit(string(existing), edit)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.snapshotExisting(ctx, relFilePath, existing); err != nil {
+		return nil, err
+	}
+
+	if err := writeFileAtomic(absPath, []byte(newContent), 0644); err != nil {
 		return nil, fmt.Errorf("write file: %w", err)
 	}
 
@@ -431,12 +564,12 @@ func (s *Service) EditFile(ctx context.Context, filename, format, path, content,
 	}
 
 	jobID := uuid.New().String()
-	_, err := s.db.Exec(ctx, `INSERT INTO jobs (id, status) VALUES ($1, 'running')`, jobID)
+	_, err = s.db.Exec(ctx, `INSERT INTO jobs (id, status) VALUES ($1, 'running')`, jobID)
 	if err != nil {
 		return nil, fmt.Errorf("create job: %w", err)
 	}
 
-	result := &IngestResult{JobID: jobID}
+	result := &IngestResult{JobID: jobID, FileHash: hash}
 
 	action, fileID, err := s.upsertFile(ctx, entry)
 	if err != nil {
@@ -465,6 +598,71 @@ func (s *Service) EditFile(ctx context.Context, filename, format, path, content,
 	return result, nil
 }
 
+type DeleteResult struct {
+	JobID     string `json:"job_id"`
+	Path      string `json:"path"`
+	TrashPath string `json:"trash_path,omitempty"`
+}
+
+func (s *Service) DeleteFile(ctx context.Context, filename, format, path string, soft bool) (*DeleteResult, error) {
+	relFilePath, err := s.resolveFile(ctx, filename, format, path)
+	if err != nil {
+		return nil, err
+	}
+
+	unlock := s.lockPath(relFilePath)
+	defer unlock()
+
+	var fileID string
+	if err := s.db.QueryRow(ctx,
+		`SELECT id FROM files WHERE path = $1`, relFilePath,
+	).Scan(&fileID); err != nil {
+		return nil, ErrNotFound
+	}
+
+	jobID := uuid.New().String()
+	_, err = s.db.Exec(ctx, `INSERT INTO jobs (id, status) VALUES ($1, 'running')`, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("create job: %w", err)
+	}
+
+	result := &DeleteResult{JobID: jobID, Path: relFilePath}
+	fail := func(err error) (*DeleteResult, error) {
+		_, _ = s.db.Exec(ctx,
+			`UPDATE jobs SET status = 'error', error = $1, updated_at = NOW() WHERE id = $2`,
+			err.Error(), jobID,
+		)
+		return nil, err
+	}
+
+	// purge the index first: if removing the file fails afterwards, the next
+	// ingest picks it up again, whereas a purge failure after the file is
+	// gone would leave rows and points nothing can reach
+	if err := s.deleteFile(ctx, fileID, relFilePath); err != nil {
+		return fail(fmt.Errorf("purge index: %w", err))
+	}
+
+	absPath := filepath.Join(s.cfg.VaultRoot, filepath.FromSlash(relFilePath))
+	if soft {
+		trashRel := filepath.ToSlash(filepath.Join(trashDir, time.Now().Format("20060102-150405"), relFilePath))
+		trashAbs := filepath.Join(s.cfg.VaultRoot, filepath.FromSlash(trashRel))
+		if err := os.MkdirAll(filepath.Dir(trashAbs), 0755); err != nil {
+			return fail(fmt.Errorf("create trash dir: %w", err))
+		}
+		if err := os.Rename(absPath, trashAbs); err != nil && !os.IsNotExist(err) {
+			return fail(fmt.Errorf("move to trash: %w", err))
+		}
+		result.TrashPath = trashRel
+	} else if err := os.Remove(absPath); err != nil && !os.IsNotExist(err) {
+		return fail(fmt.Errorf("remove file: %w", err))
+	}
+
+	_, _ = s.db.Exec(ctx,
+		`UPDATE jobs SET status = 'done', updated_at = NOW() WHERE id = $1`, jobID,
+	)
+	return result, nil
+}
+
 func sha256sum(data []byte) [32]byte {
 	return sha256.Sum256(data)
 }
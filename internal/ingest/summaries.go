@@ -0,0 +1,311 @@
+package ingest
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	summaryKind     = "summary"
+	maxSummaryInput = 12000
+	rollupDelay     = 10 * time.Second
+)
+
+const fileSummaryPrompt = `Summarize the document below for a search index of a personal knowledge base in 3-5 sentences: what it is about, the main people, projects and decisions, and any important dates. Return only the summary.
+
+Document: %s
+
+%s`
+
+const folderSummaryPrompt = `Below are summaries of the notes and subfolders in the folder "%s" of a personal knowledge base. Write a 3-5 sentence overview of what the folder as a whole is about and what can be found in it. Return only the overview.
+
+%s`
+
+func truncateRunes(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n]) + "…"
+	}
+	return s
+}
+
+func chunkTexts(chunks []Chunk) func() (string, error) {
+	return func() (string, error) {
+		texts := make([]string, len(chunks))
+		for i, c := range chunks {
+			texts[i] = c.Text
+		}
+		return strings.Join(texts, "\n\n"), nil
+	}
+}
+
+func (s *Service) storedTexts(ctx context.Context, fileID string) func() (string, error) {
+	return func() (string, error) {
+		var text string
+		err := s.db.QueryRow(ctx,
+			`SELECT COALESCE(string_agg(chunk_text, E'\n\n' ORDER BY chunk_index), '')
+			 FROM chunks WHERE file_id = $1`, fileID,
+		).Scan(&text)
+		if err != nil {
+			return "", fmt.Errorf("load chunks: %w", err)
+		}
+		return text, nil
+	}
+}
+
+// summarizeFile regenerates the file summary when files.file_hash no longer
+// matches the hash it was made from; load is only called in that case.
+func (s *Service) summarizeFile(ctx context.Context, fileID, relPath string, load func() (string, error)) error {
+	var fileHash, sourceHash string
+	err := s.db.QueryRow(ctx,
+		`SELECT f.file_hash, COALESCE(sm.source_hash, '')
+		 FROM files f
+		 LEFT JOIN summaries sm ON sm.file_id = f.id AND sm.level = 'file'
+		 WHERE f.id = $1`, fileID,
+	).Scan(&fileHash, &sourceHash)
+	if err != nil {
+		return fmt.Errorf("load file hash: %w", err)
+	}
+	if fileHash == sourceHash {
+		return nil
+	}
+
+	text, err := load()
+	if err != nil {
+		return err
+	}
+	if text = strings.TrimSpace(text); text == "" {
+		return nil
+	}
+
+	summary, err := s.llm.Generate(ctx, fmt.Sprintf(fileSummaryPrompt, relPath, truncateRunes(text, maxSummaryInput)))
+	if err != nil {
+		return fmt.Errorf("summarize %s: %w", relPath, err)
+	}
+	if err := s.storeSummary(ctx, "file", relPath, &fileID, fileHash, summary); err != nil {
+		return err
+	}
+
+	s.markFolder(path.Dir(relPath))
+	return nil
+}
+
+func (s *Service) storeSummary(ctx context.Context, level, relPath string, fileID *string, sourceHash, summary string) error {
+	if summary == "" {
+		return nil
+	}
+
+	vec, err := s.ollama.Embed(ctx, summary)
+	if err != nil {
+		return fmt.Errorf("embed summary: %w", err)
+	}
+
+	var id string
+	err = s.db.QueryRow(ctx,
+		`INSERT INTO summaries (level, path, file_id, source_hash, summary, model)
+		 VALUES ($1, $2, $3, $4, $5, $6)
+		 ON CONFLICT (level, path) DO UPDATE
+		 SET file_id = EXCLUDED.file_id, source_hash = EXCLUDED.source_hash,
+		     summary = EXCLUDED.summary, model = EXCLUDED.model, updated_at = NOW()
+		 RETURNING id`,
+		level, relPath, fileID, sourceHash, summary, s.cfg.GenerateModel,
+	).Scan(&id)
+	if err != nil {
+		return fmt.Errorf("store summary: %w", err)
+	}
+
+	payload := map[string]any{
+		"kind":       summaryKind,
+		"level":      level,
+		"chunk_id":   id,
+		"summary_id": id,
+	}
+	if level == "file" {
+		payload["file_path"] = relPath
+	} else {
+		payload["folder"] = relPath
+	}
+	if err := s.qdrant.Upsert(ctx, id, vec, payload); err != nil {
+		return fmt.Errorf("qdrant upsert: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) deleteSummaries(ctx context.Context, ids []string) error {
+	for _, id := range ids {
+		if err := s.qdrant.Delete(ctx, id); err != nil {
+			return fmt.Errorf("qdrant delete: %w", err)
+		}
+		_, _ = s.db.Exec(ctx, `DELETE FROM summaries WHERE id = $1`, id)
+	}
+	return nil
+}
+
+func (s *Service) deleteFileSummary(ctx context.Context, fileID, relPath string) error {
+	var id string
+	err := s.db.QueryRow(ctx,
+		`SELECT id FROM summaries WHERE file_id = $1 AND level = 'file'`, fileID,
+	).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("load summary: %w", err)
+	}
+	if err := s.deleteSummaries(ctx, []string{id}); err != nil {
+		return err
+	}
+	s.markFolder(path.Dir(relPath))
+	return nil
+}
+
+// moveFileSummary repoints a file's summary at its new path and returns the
+// summary's point ID, or "" if the file has none
+func moveFileSummary(ctx context.Context, tx pgx.Tx, fileID, destination string) (string, error) {
+	var id string
+	err := tx.QueryRow(ctx,
+		`UPDATE summaries SET path = $1 WHERE file_id = $2 AND level = 'file' RETURNING id`,
+		destination, fileID,
+	).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("move summary: %w", err)
+	}
+	return id, nil
+}
+
+// markFolder queues a folder for a rolled-up summary. Rollups run once writes
+// have been quiet for rollupDelay, so a bulk ingest regenerates each folder
+// only once.
+func (s *Service) markFolder(dir string) {
+	s.rollupMu.Lock()
+	defer s.rollupMu.Unlock()
+
+	if s.dirtyFolders == nil {
+		s.dirtyFolders = make(map[string]bool)
+	}
+	s.dirtyFolders[dir] = true
+
+	if s.rollupTimer == nil {
+		s.rollupTimer = time.AfterFunc(rollupDelay, s.flushFolders)
+		return
+	}
+	s.rollupTimer.Reset(rollupDelay)
+}
+
+func (s *Service) flushFolders() {
+	s.rollupMu.Lock()
+	dirs := s.dirtyFolders
+	s.dirtyFolders = nil
+	s.rollupMu.Unlock()
+
+	s.rollupRun.Lock()
+	defer s.rollupRun.Unlock()
+
+	ctx := context.Background()
+	for len(dirs) > 0 {
+		dir := deepestFolder(dirs)
+		delete(dirs, dir)
+
+		changed, err := s.summarizeFolder(ctx, dir)
+		if err != nil {
+			log.Printf("folder summary %s: %v", dir, err)
+			continue
+		}
+		if changed && dir != "." {
+			dirs[path.Dir(dir)] = true
+		}
+	}
+}
+
+func deepestFolder(dirs map[string]bool) string {
+	depth := func(dir string) int {
+		if dir == "." {
+			return 0
+		}
+		return strings.Count(dir, "/") + 1
+	}
+
+	var best string
+	for dir := range dirs {
+		if best == "" || depth(dir) > depth(best) || (depth(dir) == depth(best) && dir < best) {
+			best = dir
+		}
+	}
+	return best
+}
+
+func (s *Service) summarizeFolder(ctx context.Context, dir string) (bool, error) {
+	var existing, current string
+	err := s.db.QueryRow(ctx,
+		`SELECT id, source_hash FROM summaries WHERE level = 'folder' AND path = $1`, dir,
+	).Scan(&existing, &current)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return false, fmt.Errorf("load folder summary: %w", err)
+	}
+
+	prefix := ""
+	if dir != "." {
+		prefix = dir + "/"
+	}
+	rows, err := s.db.Query(ctx,
+		`SELECT level, path, summary FROM summaries
+		 WHERE path LIKE $1 ORDER BY path`,
+		likePrefix(prefix),
+	)
+	if err != nil {
+		return false, fmt.Errorf("load child summaries: %w", err)
+	}
+
+	var children []string
+	for rows.Next() {
+		var level, p, summary string
+		if err := rows.Scan(&level, &p, &summary); err != nil {
+			rows.Close()
+			return false, err
+		}
+		rest := strings.TrimPrefix(p, prefix)
+		if p == "." || strings.Contains(rest, "/") {
+			continue
+		}
+		children = append(children, fmt.Sprintf("- %s (%s): %s", rest, level, summary))
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return false, err
+	}
+
+	if len(children) == 0 {
+		if existing == "" {
+			return false, nil
+		}
+		return true, s.deleteSummaries(ctx, []string{existing})
+	}
+
+	sort.Strings(children)
+	listing := strings.Join(children, "\n")
+	sourceHash := fmt.Sprintf("%x", sha256.Sum256([]byte(listing)))
+	if current == sourceHash {
+		return false, nil
+	}
+
+	name := dir
+	if dir == "." {
+		name = "/"
+	}
+	summary, err := s.llm.Generate(ctx, fmt.Sprintf(folderSummaryPrompt, name, truncateRunes(listing, maxSummaryInput)))
+	if err != nil {
+		return false, fmt.Errorf("summarize folder: %w", err)
+	}
+	return true, s.storeSummary(ctx, "folder", dir, nil, sourceHash, summary)
+}
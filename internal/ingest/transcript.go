@@ -0,0 +1,140 @@
+package ingest
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const transcriptWindow = 60 * time.Second
+
+type cue struct {
+	start time.Duration
+	end   time.Duration
+	text  string
+}
+
+var (
+	cueTiming = regexp.MustCompile(`^((?:\d+:)?\d{1,2}:\d{2}[.,]\d{1,3})\s*-->\s*((?:\d+:)?\d{1,2}:\d{2}[.,]\d{1,3})`)
+	voiceTag  = regexp.MustCompile(`<v(?:\.[^ >]*)?\s+([^>]+)>`)
+	cueTag    = regexp.MustCompile(`<[^>]*>`)
+)
+
+func parseCues(content string) []cue {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	content = strings.TrimPrefix(content, "\uFEFF")
+
+	var cues []cue
+	for _, block := range strings.Split(content, "\n\n") {
+		lines := strings.Split(strings.TrimSpace(block), "\n")
+
+		timing := -1
+		for i, line := range lines {
+			if cueTiming.MatchString(strings.TrimSpace(line)) {
+				timing = i
+				break
+			}
+		}
+		if timing < 0 {
+			continue
+		}
+
+		m := cueTiming.FindStringSubmatch(strings.TrimSpace(lines[timing]))
+		start, err1 := parseTimestamp(m[1])
+		end, err2 := parseTimestamp(m[2])
+		if err1 != nil || err2 != nil {
+			continue
+		}
+
+		var text []string
+		for _, line := range lines[timing+1:] {
+			line = voiceTag.ReplaceAllString(line, "$1: ")
+			line = strings.TrimSpace(cueTag.ReplaceAllString(line, ""))
+			if line != "" {
+				text = append(text, line)
+			}
+		}
+		if len(text) == 0 {
+			continue
+		}
+
+		cues = append(cues, cue{start: start, end: end, text: strings.Join(text, " ")})
+	}
+	return cues
+}
+
+func parseTimestamp(s string) (time.Duration, error) {
+	s = strings.Replace(s, ",", ".", 1)
+	parts := strings.Split(s, ":")
+
+	var total float64
+	for _, p := range parts {
+		v, err := strconv.ParseFloat(p, 64)
+		if err != nil {
+			return 0, err
+		}
+		total = total*60 + v
+	}
+	return time.Duration(total * float64(time.Second)), nil
+}
+
+func formatTimestamp(d time.Duration) string {
+	secs := int(d / time.Second)
+	return fmt.Sprintf("%02d:%02d:%02d", secs/3600, secs/60%60, secs%60)
+}
+
+func chunkTranscript(filePath string, data []byte, _ chunkOptions) ([]Chunk, error) {
+	cues := parseCues(string(data))
+
+	var chunks []Chunk
+	var window []cue
+
+	flush := func() {
+		if len(window) == 0 {
+			return
+		}
+		start, end := window[0].start, window[len(window)-1].end
+
+		var lines []string
+		for _, c := range window {
+			lines = append(lines, c.text)
+		}
+		text := strings.Join(lines, "\n")
+
+		chunks = append(chunks, Chunk{
+			ID:       chunkID(filePath, text),
+			Text:     text,
+			Position: formatTimestamp(start) + "–" + formatTimestamp(end),
+			Payload: map[string]any{
+				"start":         formatTimestamp(start),
+				"end":           formatTimestamp(end),
+				"start_seconds": start.Seconds(),
+				"end_seconds":   end.Seconds(),
+			},
+		})
+		window = nil
+	}
+
+	size := 0
+	for _, c := range cues {
+		if len(window) > 0 && (c.end-window[0].start > transcriptWindow || estimateTokens(c.text)+size > MaxTokens) {
+			flush()
+			size = 0
+		}
+		window = append(window, c)
+		size += estimateTokens(c.text)
+	}
+	flush()
+
+	return chunks, nil
+}
+
+func transcriptText(data []byte) (string, error) {
+	var lines []string
+	for _, c := range parseCues(string(data)) {
+		lines = append(lines, fmt.Sprintf("[%s] %s", formatTimestamp(c.start), c.text))
+	}
+	return strings.Join(lines, "\n"), nil
+}
@@ -0,0 +1,26 @@
+package ingest
+
+import "testing"
+
+func TestTranscriptPayloadAfterShift(t *testing.T) {
+	before := "WEBVTT\n\n00:00:05.000 --> 00:00:09.000\nWelcome back.\n"
+	after := "WEBVTT\n\n00:00:01.000 --> 00:00:04.000\nIntro.\n\n00:01:25.000 --> 00:01:29.000\nWelcome back.\n"
+
+	old, err := chunkTranscript("talk.vtt", []byte(before), chunkOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunks, err := chunkTranscript("talk.vtt", []byte(after), chunkOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 2 || chunks[1].ID != old[0].ID {
+		t.Fatalf("expected the unchanged cue to keep its chunk, got %+v", chunks)
+	}
+
+	// the kept chunk is not re-embedded; its payload must carry the new times
+	payload := chunkPayload("talk.vtt", chunks[1], nil)
+	if payload["start"] != "00:01:25" || payload["end"] != "00:01:29" || payload["position"] != "00:01:25–00:01:29" {
+		t.Fatalf("got %v", payload)
+	}
+}
@@ -0,0 +1,298 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const maxVersionSize = 10 << 20
+
+var ErrVersionNotFound = errors.New("version not found")
+
+type FileVersion struct {
+	Version   int       `json:"version"`
+	FileHash  string    `json:"file_hash"`
+	Size      int       `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+	Current   bool      `json:"current"`
+}
+
+func (s *Service) snapshot(ctx context.Context, fileID string, content []byte) error {
+	if len(content) > maxVersionSize {
+		return nil
+	}
+	_, err := s.db.Exec(ctx,
+		`INSERT INTO file_versions (file_id, version, file_hash, content, path)
+		 SELECT id, version, $2, $3, path FROM files WHERE id = $1
+		 ON CONFLICT (file_id, version) DO UPDATE SET file_hash = EXCLUDED.file_hash, content = EXCLUDED.content`,
+		fileID, fmt.Sprintf("%x", sha256sum(content)), content,
+	)
+	if err != nil {
+		return fmt.Errorf("snapshot version: %w", err)
+	}
+	return s.pruneVersions(ctx, fileID)
+}
+
+func (s *Service) pruneVersions(ctx context.Context, fileID string) error {
+	if s.cfg.MaxVersions <= 0 {
+		return nil
+	}
+	_, err := s.db.Exec(ctx,
+		`DELETE FROM file_versions
+		 WHERE file_id = $1 AND version <= (SELECT version FROM files WHERE id = $1) - $2`,
+		fileID, s.cfg.MaxVersions,
+	)
+	if err != nil {
+		return fmt.Errorf("prune versions: %w", err)
+	}
+	return nil
+}
+
+// snapshotExisting stores the content a write is about to replace unless the
+// current version already holds it. Files indexed before version history
+// existed get their current version filled in; content edited outside the
+// API since the last snapshot becomes a new version.
+func (s *Service) snapshotExisting(ctx context.Context, relPath string, existing []byte) error {
+	if len(existing) > maxVersionSize {
+		return nil
+	}
+	hash := fmt.Sprintf("%x", sha256sum(existing))
+
+	var fileID string
+	var snapshotHash *string
+	err := s.db.QueryRow(ctx,
+		`SELECT f.id, v.file_hash FROM files f
+		 LEFT JOIN file_versions v ON v.file_id = f.id AND v.version = f.version
+		 WHERE f.path = $1`, relPath,
+	).Scan(&fileID, &snapshotHash)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("query version: %w", err)
+	}
+
+	switch {
+	case snapshotHash == nil:
+		_, err = s.db.Exec(ctx,
+			`INSERT INTO file_versions (file_id, version, file_hash, content, path)
+			 SELECT id, version, $2, $3, path FROM files WHERE id = $1
+			 ON CONFLICT (file_id, version) DO NOTHING`,
+			fileID, hash, existing,
+		)
+	case *snapshotHash != hash:
+		// a failed file keeps its version on the next write, so clear the
+		// error or that write would overwrite this snapshot
+		_, err = s.db.Exec(ctx,
+			`WITH bumped AS (
+			     UPDATE files SET version = version + 1,
+			            status = CASE WHEN status = 'error' THEN 'pending' ELSE status END
+			     WHERE id = $1 RETURNING id, version, path)
+			 INSERT INTO file_versions (file_id, version, file_hash, content, path)
+			 SELECT id, version, $2, $3, path FROM bumped`,
+			fileID, hash, existing,
+		)
+	default:
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("snapshot version: %w", err)
+	}
+	return s.pruneVersions(ctx, fileID)
+}
+
+// versionsOf selects the versions of the file at $1, or of the deleted file
+// that used to be there
+const versionsOf = `(v.file_id = (SELECT id FROM files WHERE path = $1)
+	OR (v.file_id IS NULL AND v.path = $1 AND NOT EXISTS (SELECT 1 FROM files WHERE path = $1)))`
+
+// resolveVersioned resolves like resolveFile, but also finds deleted files
+// that still have versions
+func (s *Service) resolveVersioned(ctx context.Context, filename, format, path string) (string, error) {
+	relPath, err := s.resolveFile(ctx, filename, format, path)
+	if !errors.Is(err, ErrNotFound) {
+		return relPath, err
+	}
+	if format == "" {
+		format = "md"
+	}
+
+	relPath = filepath.ToSlash(filepath.Join(path, filename+"."+format))
+	var found bool
+	err = s.db.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM file_versions WHERE file_id IS NULL AND path = $1)`, relPath,
+	).Scan(&found)
+	if err != nil {
+		return "", fmt.Errorf("query versions: %w", err)
+	}
+	if !found {
+		return "", ErrNotFound
+	}
+	return relPath, nil
+}
+
+func (s *Service) Versions(ctx context.Context, filename, format, path string) (string, []FileVersion, error) {
+	relPath, err := s.resolveVersioned(ctx, filename, format, path)
+	if err != nil {
+		return "", nil, err
+	}
+
+	rows, err := s.db.Query(ctx,
+		`SELECT v.version, v.file_hash, length(v.content), v.created_at, COALESCE(v.version = f.version, FALSE)
+		 FROM file_versions v
+		 LEFT JOIN files f ON f.id = v.file_id
+		 WHERE `+versionsOf+`
+		 ORDER BY v.version DESC`,
+		relPath,
+	)
+	if err != nil {
+		return "", nil, fmt.Errorf("query versions: %w", err)
+	}
+	defer rows.Close()
+
+	versions := []FileVersion{}
+	for rows.Next() {
+		var v FileVersion
+		if err := rows.Scan(&v.Version, &v.FileHash, &v.Size, &v.CreatedAt, &v.Current); err != nil {
+			return "", nil, err
+		}
+		versions = append(versions, v)
+	}
+	return relPath, versions, rows.Err()
+}
+
+func (s *Service) versionContent(ctx context.Context, relPath string, version int) ([]byte, error) {
+	var content []byte
+	err := s.db.QueryRow(ctx,
+		`SELECT v.content FROM file_versions v
+		 WHERE `+versionsOf+` AND v.version = $2`,
+		relPath, version,
+	).Scan(&content)
+	if err != nil {
+		return nil, ErrVersionNotFound
+	}
+	return content, nil
+}
+
+func (s *Service) GetVersion(ctx context.Context, filename, format, path string, version int) (string, string, error) {
+	relPath, err := s.resolveVersioned(ctx, filename, format, path)
+	if err != nil {
+		return "", "", err
+	}
+	content, err := s.versionContent(ctx, relPath, version)
+	if err != nil {
+		return "", "", err
+	}
+	text, err := extractText(relPath, content)
+	return relPath, text, err
+}
+
+func (s *Service) DiffVersions(ctx context.Context, filename, format, path string, from, to int) (string, string, error) {
+	relPath, err := s.resolveVersioned(ctx, filename, format, path)
+	if err != nil {
+		return "", "", err
+	}
+
+	if to == 0 {
+		if err := s.db.QueryRow(ctx,
+			`SELECT COALESCE(
+			   (SELECT version FROM files WHERE path = $1),
+			   (SELECT MAX(v.version) FROM file_versions v WHERE `+versionsOf+`))`,
+			relPath,
+		).Scan(&to); err != nil {
+			return "", "", ErrNotFound
+		}
+	}
+	if from == 0 {
+		from = to - 1
+	}
+
+	texts := make([]string, 2)
+	for i, v := range []int{from, to} {
+		content, err := s.versionContent(ctx, relPath, v)
+		if err != nil {
+			return "", "", fmt.Errorf("version %d: %w", v, err)
+		}
+		if utf8.Valid(content) {
+			texts[i] = string(content)
+		} else if texts[i], err = extractText(relPath, content); err != nil {
+			return "", "", err
+		}
+	}
+
+	return relPath, unifiedDiff(
+		fmt.Sprintf("%s@v%d", relPath, from),
+		fmt.Sprintf("%s@v%d", relPath, to),
+		texts[0], texts[1],
+	), nil
+}
+
+func (s *Service) RestoreVersion(ctx context.Context, filename, format, path string, version int) (*IngestResult, error) {
+	relPath, err := s.resolveVersioned(ctx, filename, format, path)
+	if err != nil {
+		return nil, err
+	}
+	content, err := s.versionContent(ctx, relPath, version)
+	if err != nil {
+		return nil, err
+	}
+
+	unlock := s.lockPath(relPath)
+	defer unlock()
+
+	if existing, err := os.ReadFile(filepath.Join(s.cfg.VaultRoot, filepath.FromSlash(relPath))); err == nil {
+		if err := s.snapshotExisting(ctx, relPath, existing); err != nil {
+			return nil, err
+		}
+	}
+
+	jobID := uuid.New().String()
+	_, err = s.db.Exec(ctx, `INSERT INTO jobs (id, status) VALUES ($1, 'running')`, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("create job: %w", err)
+	}
+
+	absPath, err := sanitizePath(s.cfg.VaultRoot, relPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(absPath), 0755); err != nil {
+		return nil, fmt.Errorf("create dir: %w", err)
+	}
+	if err := writeFileAtomic(absPath, content, 0644); err != nil {
+		err = fmt.Errorf("write file: %w", err)
+		_, _ = s.db.Exec(ctx,
+			`UPDATE jobs SET status = 'error', error = $1, updated_at = NOW() WHERE id = $2`,
+			err.Error(), jobID,
+		)
+		return nil, err
+	}
+
+	action, err := s.reindexFile(ctx, relPath, content)
+	if err != nil {
+		_, _ = s.db.Exec(ctx,
+			`UPDATE jobs SET status = 'error', error = $1, updated_at = NOW() WHERE id = $2`,
+			err.Error(), jobID,
+		)
+		return nil, err
+	}
+
+	_, _ = s.db.Exec(ctx,
+		`UPDATE jobs SET status = 'done', updated_at = NOW() WHERE id = $1`, jobID,
+	)
+	result := &IngestResult{JobID: jobID, FileHash: fileETag(content)}
+	if action == "skip" {
+		result.Skipped++
+	} else {
+		result.UpdatedFiles++
+	}
+	return result, nil
+}
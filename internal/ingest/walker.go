@@ -10,6 +10,28 @@ import (
 	"time"
 )
 
+const trashDir = ".trash"
+
+var ignoredDirs = []string{".git", "node_modules", ".cache", ".obsidian", trashDir, templatesDir}
+
+func ignoredPath(path string) bool {
+	for _, ignored := range ignoredDirs {
+		if strings.Contains(path, string(filepath.Separator)+ignored+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+func ignoredDir(name string) bool {
+	for _, ignored := range ignoredDirs {
+		if name == ignored {
+			return true
+		}
+	}
+	return false
+}
+
 type FileEntry struct {
 	Path         string
 	Hash         string
@@ -56,14 +78,18 @@ func WalkVault(vaultRoot string, relPath string) ([]FileEntry, error) {
 			return err
 		}
 
-		if d.IsDir() || !strings.HasSuffix(path, ".md") {
+		if d.IsDir() {
+			if path != root && ignoredDir(d.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !supportedFile(path) {
 			return nil
 		}
 
-		for _, ignored := range []string{".git", "node_modules", ".cache", ".obsidian"} {
-			if strings.Contains(path, string(filepath.Separator)+ignored+string(filepath.Separator)) {
-				return nil
-			}
+		if ignoredPath(path) {
+			return nil
 		}
 
 		hash, err := hashFile(path)
@@ -4,6 +4,7 @@ import (
 	"context"
 	"io/fs"
 	"log"
+	"os"
 	"path/filepath"
 	"strings"
 	"time"
@@ -42,7 +43,7 @@ func (s *Service) StartWatcher(ctx context.Context, relPath string) error {
 					return
 				}
 
-				if !strings.HasSuffix(strings.ToLower(event.Name), ".md") {
+				if !supportedFile(event.Name) {
 					continue
 				}
 				if strings.Contains(event.Name, string(filepath.Separator)+".") {
@@ -66,6 +67,13 @@ func (s *Service) StartWatcher(ctx context.Context, relPath string) error {
 					}
 					rel = filepath.ToSlash(rel)
 
+					if _, statErr := os.Stat(name); os.IsNotExist(statErr) {
+						if err := s.removeFile(context.Background(), rel); err != nil && err != ErrNotFound {
+							log.Printf("watcher remove error: %v", err)
+						}
+						return
+					}
+
 					dir := filepath.Dir(rel)
 					_, err = s.IngestPath(context.Background(), dir)
 					if err != nil {
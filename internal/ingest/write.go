@@ -0,0 +1,62 @@
+package ingest
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var ErrPreconditionFailed = errors.New("file changed since it was read")
+
+func (s *Service) lockPath(relPath string) func() {
+	m, _ := s.fileLocks.LoadOrStore(filepath.ToSlash(relPath), &sync.Mutex{})
+	mu := m.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
+
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpName, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmpName, path)
+}
+
+func fileETag(data []byte) string {
+	return fmt.Sprintf("%x", sha256sum(data))
+}
+
+func etagMatches(ifMatch string, data []byte) bool {
+	if ifMatch == "" || ifMatch == "*" {
+		return true
+	}
+	current := fileETag(data)
+	for _, tag := range strings.Split(ifMatch, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if strings.Trim(tag, `"`) == current {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package links
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (s *Service) LinksHandler(w http.ResponseWriter, r *http.Request) {
+	s.serveFileLinks(w, r, s.Outgoing)
+}
+
+func (s *Service) BacklinksHandler(w http.ResponseWriter, r *http.Request) {
+	s.serveFileLinks(w, r, s.Backlinks)
+}
+
+func (s *Service) serveFileLinks(w http.ResponseWriter, r *http.Request, list func(ctx context.Context, path string) ([]Link, error)) {
+	path, err := url.PathUnescape(chi.URLParam(r, "path"))
+	if err != nil || path == "" {
+		http.Error(w, "path is required", http.StatusBadRequest)
+		return
+	}
+
+	if !s.Exists(r.Context(), path) {
+		http.Error(w, "file not found", http.StatusNotFound)
+		return
+	}
+
+	links, err := list(r.Context(), path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"path":  path,
+		"links": links,
+	})
+}
+
+func (s *Service) BrokenHandler(w http.ResponseWriter, r *http.Request) {
+	links, err := s.Broken(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"count": len(links),
+		"links": links,
+	})
+}
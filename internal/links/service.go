@@ -0,0 +1,68 @@
+package links
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Service struct {
+	db *pgxpool.Pool
+}
+
+func NewService(db *pgxpool.Pool) *Service {
+	return &Service{db: db}
+}
+
+type Link struct {
+	SourcePath string    `json:"source_path"`
+	Target     string    `json:"target"`
+	TargetPath *string   `json:"target_path"`
+	Alias      *string   `json:"alias,omitempty"`
+	Anchor     *string   `json:"anchor,omitempty"`
+	Kind       string    `json:"kind"`
+	Resolved   bool      `json:"resolved"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+const selectLinks = `SELECT f.path, l.target, l.target_path, l.alias, l.anchor, l.kind, l.resolved, l.created_at
+	 FROM links l
+	 JOIN files f ON f.id = l.source_file_id`
+
+func (s *Service) Outgoing(ctx context.Context, path string) ([]Link, error) {
+	return s.list(ctx, selectLinks+` WHERE f.path = $1 ORDER BY l.created_at`, path)
+}
+
+func (s *Service) Backlinks(ctx context.Context, path string) ([]Link, error) {
+	return s.list(ctx, selectLinks+` WHERE l.target_path = $1 ORDER BY f.path`, path)
+}
+
+func (s *Service) Broken(ctx context.Context) ([]Link, error) {
+	return s.list(ctx, selectLinks+` WHERE NOT l.resolved ORDER BY f.path, l.target`)
+}
+
+func (s *Service) Exists(ctx context.Context, path string) bool {
+	var id string
+	err := s.db.QueryRow(ctx, `SELECT id FROM files WHERE path = $1`, path).Scan(&id)
+	return err == nil
+}
+
+func (s *Service) list(ctx context.Context, sql string, args ...any) ([]Link, error) {
+	rows, err := s.db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query links: %w", err)
+	}
+	defer rows.Close()
+
+	links := []Link{}
+	for rows.Next() {
+		var l Link
+		if err := rows.Scan(&l.SourcePath, &l.Target, &l.TargetPath, &l.Alias, &l.Anchor, &l.Kind, &l.Resolved, &l.CreatedAt); err != nil {
+			return nil, err
+		}
+		links = append(links, l)
+	}
+	return links, rows.Err()
+}
@@ -0,0 +1,483 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/SzymonLeja/local-memory-engine/internal/vector"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const maxClusterSize = 10
+
+var inactive = vector.MatchAny("memory_status", []any{"superseded", "contradicted"})
+
+var (
+	ErrConsolidating    = errors.New("consolidation already running")
+	ErrProposalNotFound = errors.New("proposal not found")
+	ErrProposalDecided  = errors.New("proposal already applied or rejected")
+	ErrProposalStale    = errors.New("proposal memories changed since it was made")
+)
+
+type Proposal struct {
+	ID           string     `json:"id"`
+	JobID        *string    `json:"job_id,omitempty"`
+	Content      string     `json:"content"`
+	MemoryIDs    []string   `json:"memory_ids"`
+	Contradicted []string   `json:"contradicted"`
+	Similarity   float64    `json:"similarity"`
+	Status       string     `json:"status"`
+	MemoryID     *string    `json:"memory_id,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	DecidedAt    *time.Time `json:"decided_at,omitempty"`
+
+	Memories []Memory `json:"memories,omitempty"`
+}
+
+const mergePrompt = `The facts below were saved at different times and say nearly the same thing. Merge them into one canonical fact that keeps every detail that is still true. Where a newer fact contradicts an older one, keep the newer information.
+Respond with JSON only, in the form {"fact": "<merged fact>", "contradicted": [<numbers of the facts contradicted by newer ones>]}.
+
+Facts (oldest first):
+%s`
+
+func (s *Service) StartConsolidation(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				jobID, err := s.StartConsolidate(ctx)
+				if err != nil {
+					log.Printf("memory consolidation: %v", err)
+					continue
+				}
+				log.Printf("memory consolidation started: job %s", jobID)
+			}
+		}
+	}()
+}
+
+func (s *Service) StartConsolidate(ctx context.Context) (string, error) {
+	if !s.consolidating.TryLock() {
+		return "", ErrConsolidating
+	}
+
+	jobID := uuid.New().String()
+	_, err := s.db.Exec(ctx, `INSERT INTO jobs (id, status) VALUES ($1, 'running')`, jobID)
+	if err != nil {
+		s.consolidating.Unlock()
+		return "", fmt.Errorf("create job: %w", err)
+	}
+
+	go func() {
+		defer s.consolidating.Unlock()
+
+		ctx := context.Background()
+		if err := s.consolidate(ctx, jobID); err != nil {
+			_, _ = s.db.Exec(ctx,
+				`UPDATE jobs SET status = 'error', error = $1, updated_at = NOW() WHERE id = $2`,
+				err.Error(), jobID,
+			)
+			return
+		}
+		_, _ = s.db.Exec(ctx,
+			`UPDATE jobs SET status = 'done', updated_at = NOW() WHERE id = $1`, jobID,
+		)
+	}()
+	return jobID, nil
+}
+
+type candidate struct {
+	id        string
+	content   string
+	userID    string
+	createdAt time.Time
+}
+
+func (s *Service) consolidate(ctx context.Context, jobID string) error {
+	rows, err := s.db.Query(ctx,
+		`SELECT id, content, user_id, created_at FROM memories WHERE status = 'active' ORDER BY created_at`)
+	if err != nil {
+		return fmt.Errorf("load memories: %w", err)
+	}
+	candidates, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (candidate, error) {
+		var c candidate
+		err := row.Scan(&c.id, &c.content, &c.userID, &c.createdAt)
+		return c, err
+	})
+	if err != nil {
+		return fmt.Errorf("load memories: %w", err)
+	}
+
+	rows, err = s.db.Query(ctx, `SELECT `+proposalColumns+` FROM memory_proposals WHERE status = 'pending'`)
+	if err != nil {
+		return fmt.Errorf("load proposals: %w", err)
+	}
+	proposals, err := pgx.CollectRows(rows, scanProposal)
+	if err != nil {
+		return fmt.Errorf("load proposals: %w", err)
+	}
+	candidates = unclaimed(candidates, proposals)
+
+	byID := make(map[string]candidate, len(candidates))
+	for _, c := range candidates {
+		byID[c.id] = c
+	}
+
+	clustered := make(map[string]bool)
+	for _, c := range candidates {
+		if clustered[c.id] {
+			continue
+		}
+
+		filter := &vector.Filter{
+			Must:    []vector.Condition{vector.MatchValue("kind", Kind)},
+			MustNot: []vector.Condition{inactive},
+		}
+		if c.userID != "" {
+			filter.Must = append(filter.Must, vector.MatchValue("user_id", c.userID))
+		}
+		hits, err := s.qdrant.Recommend(ctx, []string{c.id}, maxClusterSize, vector.SearchOptions{
+			Filter:         filter,
+			ScoreThreshold: s.cfg.MemoryMergeThreshold,
+		})
+		if err != nil {
+			return fmt.Errorf("qdrant recommend: %w", err)
+		}
+
+		cluster := []candidate{c}
+		var total float64
+		for _, h := range hits {
+			id, _ := h.Payload["memory_id"].(string)
+			if other, ok := byID[id]; ok && id != c.id && !clustered[id] && other.userID == c.userID {
+				cluster = append(cluster, other)
+				total += h.Score
+			}
+		}
+		if len(cluster) < 2 {
+			continue
+		}
+		for _, m := range cluster {
+			clustered[m.id] = true
+		}
+
+		proposalID, err := s.propose(ctx, jobID, cluster, total/float64(len(cluster)-1))
+		if err != nil {
+			log.Printf("memory consolidation: %v", err)
+			continue
+		}
+		if proposalID != "" && s.cfg.MemoryAutoApply {
+			if _, err := s.ApplyProposal(ctx, proposalID); err != nil {
+				log.Printf("memory consolidation apply %s: %v", proposalID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// unclaimed drops the memories a pending proposal is waiting on; applied,
+// rejected and stale proposals no longer hold theirs
+func unclaimed(candidates []candidate, proposals []Proposal) []candidate {
+	claimed := make(map[string]bool)
+	for _, p := range proposals {
+		if p.Status != "pending" {
+			continue
+		}
+		for _, id := range p.MemoryIDs {
+			claimed[id] = true
+		}
+	}
+	out := candidates[:0]
+	for _, c := range candidates {
+		if !claimed[c.id] {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func (s *Service) propose(ctx context.Context, jobID string, cluster []candidate, similarity float64) (string, error) {
+	sort.Slice(cluster, func(i, j int) bool { return cluster[i].createdAt.Before(cluster[j].createdAt) })
+	ids := make([]string, len(cluster))
+	for i, m := range cluster {
+		ids[i] = m.id
+	}
+
+	var rejected bool
+	err := s.db.QueryRow(ctx,
+		`SELECT EXISTS (
+		     SELECT 1 FROM memory_proposals
+		     WHERE status = 'rejected' AND memory_ids @> $1::uuid[] AND memory_ids <@ $1::uuid[])`,
+		ids,
+	).Scan(&rejected)
+	if err != nil || rejected {
+		return "", err
+	}
+
+	var facts strings.Builder
+	for i, m := range cluster {
+		fmt.Fprintf(&facts, "%d. (%s) %s\n", i+1, m.createdAt.Format(time.DateOnly), m.content)
+	}
+	resp, err := s.llm.Generate(ctx, fmt.Sprintf(mergePrompt, strings.TrimSpace(facts.String())))
+	if err != nil {
+		return "", fmt.Errorf("merge memories: %w", err)
+	}
+
+	var merged struct {
+		Fact         string `json:"fact"`
+		Contradicted []int  `json:"contradicted"`
+	}
+	start, end := strings.Index(resp, "{"), strings.LastIndex(resp, "}")
+	if start < 0 || end < start {
+		return "", fmt.Errorf("merge memories: no JSON in response")
+	}
+	if err := json.Unmarshal([]byte(resp[start:end+1]), &merged); err != nil {
+		return "", fmt.Errorf("merge memories: %w", err)
+	}
+	if merged.Fact = strings.TrimSpace(merged.Fact); merged.Fact == "" {
+		return "", fmt.Errorf("merge memories: empty fact")
+	}
+
+	contradicted := []string{}
+	for _, n := range merged.Contradicted {
+		if n >= 1 && n <= len(cluster) {
+			contradicted = append(contradicted, ids[n-1])
+		}
+	}
+
+	var proposalID string
+	err = s.db.QueryRow(ctx,
+		`INSERT INTO memory_proposals (job_id, memory_ids, contradicted, content, similarity)
+		 VALUES ($1, $2, $3, $4, $5)
+		 RETURNING id`,
+		jobID, ids, contradicted, merged.Fact, similarity,
+	).Scan(&proposalID)
+	if err != nil {
+		return "", fmt.Errorf("insert proposal: %w", err)
+	}
+	return proposalID, nil
+}
+
+const proposalColumns = `id, job_id, content, memory_ids::text[], contradicted::text[], COALESCE(similarity, 0), status, memory_id, created_at, decided_at`
+
+func scanProposal(row pgx.CollectableRow) (Proposal, error) {
+	var p Proposal
+	err := row.Scan(&p.ID, &p.JobID, &p.Content, &p.MemoryIDs, &p.Contradicted, &p.Similarity, &p.Status, &p.MemoryID, &p.CreatedAt, &p.DecidedAt)
+	return p, err
+}
+
+func (s *Service) Proposals(ctx context.Context, status, jobID string) ([]Proposal, error) {
+	rows, err := s.db.Query(ctx,
+		`SELECT `+proposalColumns+`
+		 FROM memory_proposals
+		 WHERE ($1 = '' OR status = $1) AND ($2 = '' OR job_id::text = $2)
+		 ORDER BY created_at DESC`,
+		status, jobID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list proposals: %w", err)
+	}
+	proposals, err := pgx.CollectRows(rows, scanProposal)
+	if err != nil {
+		return nil, fmt.Errorf("list proposals: %w", err)
+	}
+
+	for i := range proposals {
+		rows, err := s.db.Query(ctx,
+			`SELECT `+memoryColumns+` FROM memories WHERE id = ANY($1::uuid[]) ORDER BY created_at`,
+			proposals[i].MemoryIDs,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("proposal memories: %w", err)
+		}
+		proposals[i].Memories, err = pgx.CollectRows(rows, scanMemory)
+		if err != nil {
+			return nil, fmt.Errorf("proposal memories: %w", err)
+		}
+	}
+	return proposals, nil
+}
+
+func (s *Service) pendingProposal(ctx context.Context, id string) (*Proposal, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrProposalNotFound
+	}
+	rows, err := s.db.Query(ctx, `SELECT `+proposalColumns+` FROM memory_proposals WHERE id = $1`, id)
+	if err != nil {
+		return nil, fmt.Errorf("get proposal: %w", err)
+	}
+	p, err := pgx.CollectExactlyOneRow(rows, scanProposal)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrProposalNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get proposal: %w", err)
+	}
+	if p.Status != "pending" {
+		return nil, ErrProposalDecided
+	}
+	return &p, nil
+}
+
+func (s *Service) ApplyProposal(ctx context.Context, id string) (*Memory, error) {
+	p, err := s.pendingProposal(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	vec, err := s.ollama.Embed(ctx, p.Content)
+	if err != nil {
+		return nil, fmt.Errorf("embed memory: %w", err)
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("apply proposal: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// claiming the proposal first makes concurrent applies or rejects of
+	// the same proposal wait on the row lock and then find it decided. A
+	// proposal whose memories changed is closed as stale in the same
+	// statement so the next run can cluster them again.
+	var status string
+	err = tx.QueryRow(ctx,
+		`UPDATE memory_proposals
+		 SET status = CASE
+		         WHEN (SELECT COUNT(*) FROM memories WHERE id = ANY(memory_ids) AND status = 'active') = cardinality(memory_ids)
+		         THEN 'applied' ELSE 'stale' END,
+		     decided_at = NOW()
+		 WHERE id = $1 AND status = 'pending'
+		 RETURNING status`, p.ID,
+	).Scan(&status)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrProposalDecided
+	}
+	if err != nil {
+		return nil, fmt.Errorf("update proposal: %w", err)
+	}
+	if status == "stale" {
+		if err := tx.Commit(ctx); err != nil {
+			return nil, fmt.Errorf("update proposal: %w", err)
+		}
+		return nil, ErrProposalStale
+	}
+
+	var userID string
+	var importance float64
+	var conversations []string
+	err = tx.QueryRow(ctx,
+		`SELECT COALESCE(MIN(user_id), ''), COALESCE(MAX(importance), 0),
+		        COALESCE(array_agg(DISTINCT conversation_id), '{}')
+		 FROM memories WHERE id = ANY($1::uuid[]) AND status = 'active'`,
+		p.MemoryIDs,
+	).Scan(&userID, &importance, &conversations)
+	if err != nil {
+		return nil, fmt.Errorf("proposal memories: %w", err)
+	}
+
+	m := Memory{Content: p.Content, UserID: userID, Importance: importance}
+	if len(conversations) == 1 {
+		m.ConversationID = conversations[0]
+	}
+	err = tx.QueryRow(ctx,
+		`INSERT INTO memories (content, conversation_id, user_id, importance)
+		 VALUES ($1, $2, $3, $4)
+		 RETURNING id, status, created_at`,
+		m.Content, m.ConversationID, m.UserID, m.Importance,
+	).Scan(&m.ID, &m.Status, &m.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("insert memory: %w", err)
+	}
+
+	// the status check in the WHERE clause is re-evaluated after waiting on
+	// a concurrent writer, so an original taken by another proposal is missed
+	tag, err := tx.Exec(ctx,
+		`UPDATE memories
+		 SET status = CASE WHEN id = ANY($2::uuid[]) THEN 'contradicted' ELSE 'superseded' END,
+		     superseded_by = $3
+		 WHERE id = ANY($1::uuid[]) AND status = 'active'`,
+		p.MemoryIDs, p.Contradicted, m.ID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("mark superseded: %w", err)
+	}
+	if tag.RowsAffected() != int64(len(p.MemoryIDs)) {
+		tx.Rollback(ctx)
+		s.markStale(ctx, p.ID)
+		return nil, ErrProposalStale
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE memory_proposals SET memory_id = $1 WHERE id = $2`, m.ID, p.ID)
+	if err != nil {
+		return nil, fmt.Errorf("update proposal: %w", err)
+	}
+
+	if err := s.qdrant.Upsert(ctx, m.ID, vec, memoryPayload(m)); err != nil {
+		return nil, fmt.Errorf("qdrant upsert: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		_ = s.qdrant.Delete(ctx, m.ID)
+		return nil, fmt.Errorf("apply proposal: %w", err)
+	}
+
+	contradicted := make(map[string]bool, len(p.Contradicted))
+	for _, cid := range p.Contradicted {
+		contradicted[cid] = true
+	}
+	var superseded []string
+	for _, mid := range p.MemoryIDs {
+		if !contradicted[mid] {
+			superseded = append(superseded, mid)
+		}
+	}
+	for status, ids := range map[string][]string{"superseded": superseded, "contradicted": p.Contradicted} {
+		if len(ids) == 0 {
+			continue
+		}
+		if err := s.qdrant.SetPayload(ctx, ids, map[string]any{"memory_status": status}); err != nil {
+			return nil, fmt.Errorf("qdrant set payload: %w", err)
+		}
+	}
+
+	m.Sources = p.MemoryIDs
+	return &m, nil
+}
+
+// markStale closes a proposal whose memories were changed by another
+// proposal between the claim and the update
+func (s *Service) markStale(ctx context.Context, id string) {
+	_, err := s.db.Exec(ctx,
+		`UPDATE memory_proposals SET status = 'stale', decided_at = NOW() WHERE id = $1 AND status = 'pending'`, id,
+	)
+	if err != nil {
+		log.Printf("mark proposal %s stale: %v", id, err)
+	}
+}
+
+func (s *Service) RejectProposal(ctx context.Context, id string) error {
+	if _, err := s.pendingProposal(ctx, id); err != nil {
+		return err
+	}
+	tag, err := s.db.Exec(ctx,
+		`UPDATE memory_proposals SET status = 'rejected', decided_at = NOW()
+		 WHERE id = $1 AND status = 'pending'`, id,
+	)
+	if err != nil {
+		return fmt.Errorf("update proposal: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrProposalDecided
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package memory
+
+import "testing"
+
+func TestUnclaimedReleasesStaleProposals(t *testing.T) {
+	candidates := func() []candidate {
+		return []candidate{{id: "a"}, {id: "b"}, {id: "c"}}
+	}
+	proposal := Proposal{MemoryIDs: []string{"a", "b"}, Status: "pending"}
+
+	if got := unclaimed(candidates(), []Proposal{proposal}); len(got) != 1 || got[0].id != "c" {
+		t.Fatalf("pending proposal should hold its memories, got %v", got)
+	}
+
+	// once ApplyProposal closes the proposal as stale, the next run clusters
+	// its memories again
+	proposal.Status = "stale"
+	if got := unclaimed(candidates(), []Proposal{proposal}); len(got) != 3 {
+		t.Fatalf("stale proposal should release its memories, got %v", got)
+	}
+}
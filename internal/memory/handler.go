@@ -0,0 +1,198 @@
+package memory
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func (s *Service) CreateHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Content        string    `json:"content"`
+		Messages       []Message `json:"messages"`
+		ConversationID string    `json:"conversation_id"`
+		UserID         string    `json:"user_id"`
+		Importance     *float64  `json:"importance"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	req.Content = strings.TrimSpace(req.Content)
+	if (req.Content == "") == (len(req.Messages) == 0) {
+		http.Error(w, "exactly one of content or messages is required", http.StatusBadRequest)
+		return
+	}
+	importance := defaultImportance
+	if req.Importance != nil {
+		if *req.Importance < 0 || *req.Importance > 1 {
+			http.Error(w, "importance must be between 0 and 1", http.StatusBadRequest)
+			return
+		}
+		importance = *req.Importance
+	}
+
+	facts := []string{req.Content}
+	if len(req.Messages) > 0 {
+		var err error
+		facts, err = s.Extract(r.Context(), req.Messages)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	created := []Memory{}
+	for _, fact := range facts {
+		m, err := s.Create(r.Context(), Memory{
+			Content:        fact,
+			ConversationID: req.ConversationID,
+			UserID:         req.UserID,
+			Importance:     importance,
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		created = append(created, *m)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]any{
+		"count":    len(created),
+		"memories": created,
+	})
+}
+
+func (s *Service) ListHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	opts := ListOptions{
+		Status:         q.Get("status"),
+		UserID:         q.Get("user_id"),
+		ConversationID: q.Get("conversation_id"),
+		Limit:          50,
+	}
+	if opts.Status != "" && opts.Status != "active" && opts.Status != "superseded" && opts.Status != "contradicted" {
+		http.Error(w, "status must be active, superseded or contradicted", http.StatusBadRequest)
+		return
+	}
+	if v, err := strconv.Atoi(q.Get("limit")); err == nil && v > 0 {
+		opts.Limit = min(v, 500)
+	}
+	if v, err := strconv.Atoi(q.Get("offset")); err == nil && v > 0 {
+		opts.Offset = v
+	}
+
+	list, err := s.List(r.Context(), opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+func (s *Service) GetHandler(w http.ResponseWriter, r *http.Request) {
+	m, err := s.Get(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(m)
+}
+
+func (s *Service) DeleteHandler(w http.ResponseWriter, r *http.Request) {
+	if err := s.Delete(r.Context(), chi.URLParam(r, "id")); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Service) ConsolidateHandler(w http.ResponseWriter, r *http.Request) {
+	jobID, err := s.StartConsolidate(r.Context())
+	if err != nil {
+		if errors.Is(err, ErrConsolidating) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{"job_id": jobID})
+}
+
+func (s *Service) ProposalsHandler(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = "pending"
+	}
+	if status != "pending" && status != "applied" && status != "rejected" && status != "stale" {
+		http.Error(w, "status must be pending, applied, rejected or stale", http.StatusBadRequest)
+		return
+	}
+
+	proposals, err := s.Proposals(r.Context(), status, r.URL.Query().Get("job_id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"count":     len(proposals),
+		"proposals": proposals,
+	})
+}
+
+func (s *Service) ApplyProposalHandler(w http.ResponseWriter, r *http.Request) {
+	m, err := s.ApplyProposal(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeProposalError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(m)
+}
+
+func (s *Service) RejectProposalHandler(w http.ResponseWriter, r *http.Request) {
+	if err := s.RejectProposal(r.Context(), chi.URLParam(r, "id")); err != nil {
+		writeProposalError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeProposalError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrProposalNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrProposalDecided), errors.Is(err, ErrProposalStale):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,268 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SzymonLeja/local-memory-engine/internal/config"
+	"github.com/SzymonLeja/local-memory-engine/internal/embeddings"
+	"github.com/SzymonLeja/local-memory-engine/internal/vector"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	Kind = "memory"
+
+	defaultImportance = 0.5
+	maxExtracted      = 10
+	maxMessageChars   = 2000
+)
+
+var ErrNotFound = errors.New("memory not found")
+
+type Service struct {
+	db     *pgxpool.Pool
+	cfg    *config.Config
+	ollama *embeddings.OllamaClient
+	llm    *embeddings.OllamaClient
+	qdrant *vector.QdrantClient
+
+	consolidating sync.Mutex
+}
+
+func NewService(
+	db *pgxpool.Pool,
+	cfg *config.Config,
+	ollama *embeddings.OllamaClient,
+	llm *embeddings.OllamaClient,
+	qdrant *vector.QdrantClient,
+) *Service {
+	return &Service{db: db, cfg: cfg, ollama: ollama, llm: llm, qdrant: qdrant}
+}
+
+type Memory struct {
+	ID             string    `json:"id"`
+	Content        string    `json:"content"`
+	ConversationID string    `json:"conversation_id,omitempty"`
+	UserID         string    `json:"user_id,omitempty"`
+	Importance     float64   `json:"importance"`
+	Status         string    `json:"status"`
+	SupersededBy   *string   `json:"superseded_by,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+
+	Sources []string `json:"sources,omitempty"`
+}
+
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type ListOptions struct {
+	Status         string
+	UserID         string
+	ConversationID string
+	Limit          int
+	Offset         int
+}
+
+type MemoryList struct {
+	Total    int      `json:"total"`
+	Limit    int      `json:"limit"`
+	Offset   int      `json:"offset"`
+	Memories []Memory `json:"memories"`
+}
+
+const extractPrompt = `Extract the facts worth remembering from the conversation below: decisions, preferences, names, dates, plans and other details the user may ask about later. Skip greetings, questions and anything that only matters for this exchange.
+Return each fact as a short standalone sentence, one per line, without numbering or commentary. Return nothing if there is nothing worth remembering.
+
+Conversation:
+%s`
+
+var listMarker = regexp.MustCompile(`^\s*(?:[-*•]|\d+[.)])\s*`)
+
+func (s *Service) Extract(ctx context.Context, messages []Message) ([]string, error) {
+	var conversation strings.Builder
+	for _, m := range messages {
+		content := strings.TrimSpace(m.Content)
+		if r := []rune(content); len(r) > maxMessageChars {
+			content = string(r[:maxMessageChars]) + "…"
+		}
+		fmt.Fprintf(&conversation, "%s: %s\n", m.Role, content)
+	}
+
+	resp, err := s.llm.Generate(ctx, fmt.Sprintf(extractPrompt, strings.TrimSpace(conversation.String())))
+	if err != nil {
+		return nil, fmt.Errorf("extract memories: %w", err)
+	}
+
+	var facts []string
+	for _, line := range strings.Split(resp, "\n") {
+		line = strings.TrimSpace(listMarker.ReplaceAllString(line, ""))
+		if line == "" {
+			continue
+		}
+		facts = append(facts, line)
+		if len(facts) == maxExtracted {
+			break
+		}
+	}
+	return facts, nil
+}
+
+func (s *Service) Create(ctx context.Context, m Memory) (*Memory, error) {
+	vec, err := s.ollama.Embed(ctx, m.Content)
+	if err != nil {
+		return nil, fmt.Errorf("embed memory: %w", err)
+	}
+
+	err = s.db.QueryRow(ctx,
+		`INSERT INTO memories (content, conversation_id, user_id, importance)
+		 VALUES ($1, $2, $3, $4)
+		 RETURNING id, status, created_at`,
+		m.Content, m.ConversationID, m.UserID, m.Importance,
+	).Scan(&m.ID, &m.Status, &m.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("insert memory: %w", err)
+	}
+
+	if err := s.qdrant.Upsert(ctx, m.ID, vec, memoryPayload(m)); err != nil {
+		_, _ = s.db.Exec(ctx, `DELETE FROM memories WHERE id = $1`, m.ID)
+		return nil, fmt.Errorf("qdrant upsert: %w", err)
+	}
+	return &m, nil
+}
+
+// chunk_id carries the memory ID so sessions, MMR and provenance treat
+// memories like any other hit; memory_status avoids clashing with a
+// front-matter status copied into chunk payloads
+func memoryPayload(m Memory) map[string]any {
+	return map[string]any{
+		"kind":            Kind,
+		"chunk_id":        m.ID,
+		"memory_id":       m.ID,
+		"conversation_id": m.ConversationID,
+		"user_id":         m.UserID,
+		"importance":      m.Importance,
+		"memory_status":   m.Status,
+		"created_at":      m.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+const memoryColumns = `id, content, conversation_id, user_id, importance, status, superseded_by, created_at`
+
+func scanMemory(row pgx.CollectableRow) (Memory, error) {
+	var m Memory
+	err := row.Scan(&m.ID, &m.Content, &m.ConversationID, &m.UserID, &m.Importance, &m.Status, &m.SupersededBy, &m.CreatedAt)
+	return m, err
+}
+
+func (s *Service) Get(ctx context.Context, id string) (*Memory, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, ErrNotFound
+	}
+
+	rows, err := s.db.Query(ctx,
+		`SELECT `+memoryColumns+` FROM memories WHERE id = $1`, id)
+	if err != nil {
+		return nil, fmt.Errorf("get memory: %w", err)
+	}
+	m, err := pgx.CollectExactlyOneRow(rows, scanMemory)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get memory: %w", err)
+	}
+
+	err = s.db.QueryRow(ctx,
+		`SELECT COALESCE(array_agg(id::text ORDER BY created_at), '{}') FROM memories WHERE superseded_by = $1`, id,
+	).Scan(&m.Sources)
+	if err != nil {
+		return nil, fmt.Errorf("memory sources: %w", err)
+	}
+	return &m, nil
+}
+
+func (s *Service) List(ctx context.Context, opts ListOptions) (*MemoryList, error) {
+	list := &MemoryList{Limit: opts.Limit, Offset: opts.Offset, Memories: []Memory{}}
+	err := s.db.QueryRow(ctx,
+		`SELECT COUNT(*) FROM memories
+		 WHERE ($1 = '' OR user_id = $1) AND ($2 = '' OR conversation_id = $2) AND ($3 = '' OR status = $3)`,
+		opts.UserID, opts.ConversationID, opts.Status,
+	).Scan(&list.Total)
+	if err != nil {
+		return nil, fmt.Errorf("count memories: %w", err)
+	}
+
+	rows, err := s.db.Query(ctx,
+		`SELECT `+memoryColumns+`
+		 FROM memories
+		 WHERE ($1 = '' OR user_id = $1) AND ($2 = '' OR conversation_id = $2) AND ($3 = '' OR status = $3)
+		 ORDER BY created_at DESC
+		 LIMIT $4 OFFSET $5`,
+		opts.UserID, opts.ConversationID, opts.Status, opts.Limit, opts.Offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list memories: %w", err)
+	}
+
+	memories, err := pgx.CollectRows(rows, scanMemory)
+	if err != nil {
+		return nil, fmt.Errorf("list memories: %w", err)
+	}
+	list.Memories = append(list.Memories, memories...)
+	return list, nil
+}
+
+func (s *Service) Delete(ctx context.Context, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return ErrNotFound
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("delete memory: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// deleting a canonical memory brings back the memories it replaced
+	rows, err := tx.Query(ctx,
+		`UPDATE memories SET status = 'active', superseded_by = NULL
+		 WHERE superseded_by = $1 RETURNING id`, id)
+	if err != nil {
+		return fmt.Errorf("restore memories: %w", err)
+	}
+	restored, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return fmt.Errorf("restore memories: %w", err)
+	}
+
+	tag, err := tx.Exec(ctx, `DELETE FROM memories WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("delete memory: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("delete memory: %w", err)
+	}
+
+	if err := s.qdrant.Delete(ctx, id); err != nil {
+		return fmt.Errorf("qdrant delete: %w", err)
+	}
+	if len(restored) > 0 {
+		if err := s.qdrant.SetPayload(ctx, restored, map[string]any{"memory_status": "active"}); err != nil {
+			return fmt.Errorf("qdrant set payload: %w", err)
+		}
+	}
+	return nil
+}
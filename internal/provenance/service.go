@@ -22,14 +22,16 @@ type ProvenanceLog struct {
 	UsedChunks    []map[string]any `json:"used_chunks"`
 	QueryDuration int              `json:"duration_ms"`
 	CreatedAt     time.Time        `json:"created_at"`
+
+	Transformations []map[string]any `json:"transformations,omitempty"`
 }
 
 func (s *Service) GetByID(ctx context.Context, id string) (*ProvenanceLog, error) {
 	var p ProvenanceLog
 	err := s.db.QueryRow(ctx,
-		`SELECT id, query_text, used_chunks, query_duration, created_at
+		`SELECT id, query_text, used_chunks, query_duration, created_at, transformations
 		 FROM provenance_log WHERE id = $1`, id,
-	).Scan(&p.ID, &p.QueryText, &p.UsedChunks, &p.QueryDuration, &p.CreatedAt)
+	).Scan(&p.ID, &p.QueryText, &p.UsedChunks, &p.QueryDuration, &p.CreatedAt, &p.Transformations)
 	if err != nil {
 		return nil, fmt.Errorf("provenance not found: %w", err)
 	}
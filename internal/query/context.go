@@ -0,0 +1,111 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+type ContextChunk struct {
+	ChunkID    string `json:"chunk_id"`
+	ChunkIndex int    `json:"chunk_index"`
+	Position   string `json:"position"`
+	ChunkText  string `json:"chunk_text"`
+	Hit        bool   `json:"hit"`
+}
+
+type chunkSpan struct {
+	file   string
+	lo, hi int
+	owner  int
+}
+
+func (s *Service) attachContext(ctx context.Context, results []ChunkResult, window int) ([]ChunkResult, error) {
+	byFile := make(map[string][]*chunkSpan)
+	for _, r := range results {
+		if r.index == nil {
+			continue
+		}
+		byFile[r.FilePath] = append(byFile[r.FilePath], &chunkSpan{
+			file:  r.FilePath,
+			lo:    max(*r.index-window, 0),
+			hi:    *r.index + window,
+			owner: -1,
+		})
+	}
+
+	for file, spans := range byFile {
+		sort.Slice(spans, func(i, j int) bool { return spans[i].lo < spans[j].lo })
+		merged := []*chunkSpan{spans[0]}
+		for _, sp := range spans[1:] {
+			last := merged[len(merged)-1]
+			if sp.lo <= last.hi+1 {
+				last.hi = max(last.hi, sp.hi)
+				continue
+			}
+			merged = append(merged, sp)
+		}
+		byFile[file] = merged
+	}
+
+	hits := make(map[string]bool, len(results))
+	for _, r := range results {
+		hits[r.ChunkID] = true
+	}
+
+	out := []ChunkResult{}
+	for i, r := range results {
+		if r.index == nil {
+			out = append(out, r)
+			continue
+		}
+
+		var span *chunkSpan
+		for _, sp := range byFile[r.FilePath] {
+			if *r.index >= sp.lo && *r.index <= sp.hi {
+				span = sp
+				break
+			}
+		}
+		if span.owner >= 0 {
+			continue
+		}
+		span.owner = i
+
+		chunks, err := s.fileChunks(ctx, span.file, span.lo, span.hi)
+		if err != nil {
+			return nil, err
+		}
+		for j := range chunks {
+			chunks[j].Hit = hits[chunks[j].ChunkID]
+		}
+		r.Context = chunks
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+func (s *Service) fileChunks(ctx context.Context, filePath string, lo, hi int) ([]ContextChunk, error) {
+	rows, err := s.db.Query(ctx,
+		`SELECT c.id, c.chunk_index, c.position, c.chunk_text
+		 FROM chunks c
+		 JOIN files f ON f.id = c.file_id
+		 WHERE f.path = $1 AND c.chunk_index BETWEEN $2 AND $3
+		 ORDER BY c.chunk_index`,
+		filePath, lo, hi,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query context chunks: %w", err)
+	}
+	defer rows.Close()
+
+	var chunks []ContextChunk
+	for rows.Next() {
+		var c ContextChunk
+		if err := rows.Scan(&c.ChunkID, &c.ChunkIndex, &c.Position, &c.ChunkText); err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, c)
+	}
+	return chunks, rows.Err()
+}
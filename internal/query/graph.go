@@ -0,0 +1,103 @@
+package query
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/SzymonLeja/local-memory-engine/internal/vector"
+)
+
+const maxLinkHops = 2
+
+func (s *Service) linkNeighbors(ctx context.Context, seeds []string, hops int) (map[string][]string, error) {
+	via := make(map[string][]string, len(seeds))
+	for _, p := range seeds {
+		via[p] = []string{p}
+	}
+
+	frontier := seeds
+	for hop := 0; hop < min(hops, maxLinkHops) && len(frontier) > 0; hop++ {
+		rows, err := s.db.Query(ctx,
+			`SELECT f.path, l.target_path
+			 FROM links l
+			 JOIN files f ON f.id = l.source_file_id
+			 WHERE l.resolved AND (f.path = ANY($1) OR l.target_path = ANY($1))`,
+			frontier,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("query links: %w", err)
+		}
+
+		inFrontier := make(map[string]bool, len(frontier))
+		for _, p := range frontier {
+			inFrontier[p] = true
+		}
+
+		var next []string
+		for rows.Next() {
+			var source, target string
+			if err := rows.Scan(&source, &target); err != nil {
+				continue
+			}
+			for _, edge := range [][2]string{{source, target}, {target, source}} {
+				from, to := edge[0], edge[1]
+				if !inFrontier[from] {
+					continue
+				}
+				if _, seen := via[to]; seen {
+					continue
+				}
+				via[to] = append(append([]string{}, via[from]...), to)
+				next = append(next, to)
+			}
+		}
+		rows.Close()
+		frontier = next
+	}
+
+	for _, p := range seeds {
+		delete(via, p)
+	}
+	return via, nil
+}
+
+func (s *Service) expandLinks(ctx context.Context, vec []float64, hits []vector.SearchResult, topK int, minScore float64, opts Options) ([]vector.SearchResult, map[string][]string, error) {
+	var seeds []string
+	seen := make(map[string]bool)
+	for _, h := range hits {
+		p, _ := h.Payload["file_path"].(string)
+		if p != "" && !seen[p] {
+			seen[p] = true
+			seeds = append(seeds, p)
+		}
+	}
+
+	neighbors, err := s.linkNeighbors(ctx, seeds, opts.ExpandLinks)
+	if err != nil || len(neighbors) == 0 {
+		return nil, nil, err
+	}
+
+	paths := make([]any, 0, len(neighbors))
+	for p := range neighbors {
+		paths = append(paths, p)
+	}
+
+	filter := levelFilter(payloadFilter(opts.Filter), opts.Level)
+	filter.Must = append(filter.Must, vector.MatchAny("file_path", paths))
+
+	found, err := s.qdrant.Search(ctx, vec, topK, vector.SearchOptions{
+		Filter:         filter,
+		ScoreThreshold: minScore,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("qdrant search: %w", err)
+	}
+
+	via := make(map[string][]string, len(found))
+	for _, h := range found {
+		chunkID, _ := h.Payload["chunk_id"].(string)
+		p, _ := h.Payload["file_path"].(string)
+		via[chunkID] = neighbors[p]
+	}
+	return found, via, nil
+}
@@ -1,13 +1,20 @@
 package query
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
 )
 
 type queryRequest struct {
 	Q    string `json:"q"`
 	TopK int    `json:"top_k"`
+	Options
 }
 
 func (s *Service) QueryHandler(w http.ResponseWriter, r *http.Request) {
@@ -17,16 +24,103 @@ func (s *Service) QueryHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.Q == "" {
+		for i := len(req.Messages) - 1; i >= 0; i-- {
+			if req.Messages[i].Role == "user" {
+				req.Q = req.Messages[i].Content
+				break
+			}
+		}
+	}
+
 	if req.Q == "" {
 		http.Error(w, "q is required", http.StatusBadRequest)
 		return
 	}
 
+	for _, t := range req.Transforms {
+		if t != "multi" && t != "hyde" {
+			http.Error(w, "transforms must be multi or hyde", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if req.Memories != "" && req.Memories != "include" && req.Memories != "exclude" && req.Memories != "only" {
+		http.Error(w, "memories must be include, exclude or only", http.StatusBadRequest)
+		return
+	}
+
+	switch req.Level {
+	case "", "chunk":
+	case "file", "folder":
+		if req.Memories == "only" {
+			http.Error(w, "memories=only requires level=chunk", http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, "level must be chunk, file or folder", http.StatusBadRequest)
+		return
+	}
+
+	if req.HalfLifeDays < 0 {
+		http.Error(w, "half_life_days must be greater than 0", http.StatusBadRequest)
+		return
+	}
+
+	if req.AsOf != "" {
+		if _, ok := parseNoteDate(req.AsOf); !ok {
+			http.Error(w, "as_of must be a date (YYYY-MM-DD) or RFC 3339 timestamp", http.StatusBadRequest)
+			return
+		}
+	}
+
 	if req.TopK <= 0 {
 		req.TopK = 5
 	}
 
-	result, err := s.Query(r.Context(), req.Q, req.TopK)
+	result, err := s.Query(r.Context(), req.Q, req.TopK, req.Options)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+func (s *Service) SimilarFileHandler(w http.ResponseWriter, r *http.Request) {
+	filePath, err := url.PathUnescape(chi.URLParam(r, "path"))
+	if err != nil || filePath == "" {
+		http.Error(w, "path is required", http.StatusBadRequest)
+		return
+	}
+	s.serveSimilar(w, r, func(ctx context.Context, topK int) (*SimilarResult, error) {
+		return s.SimilarToFile(ctx, filePath, topK)
+	})
+}
+
+func (s *Service) SimilarChunkHandler(w http.ResponseWriter, r *http.Request) {
+	chunkID := chi.URLParam(r, "id")
+	if chunkID == "" {
+		http.Error(w, "id is required", http.StatusBadRequest)
+		return
+	}
+	s.serveSimilar(w, r, func(ctx context.Context, topK int) (*SimilarResult, error) {
+		return s.SimilarToChunk(ctx, chunkID, topK)
+	})
+}
+
+func (s *Service) serveSimilar(w http.ResponseWriter, r *http.Request, similar func(ctx context.Context, topK int) (*SimilarResult, error)) {
+	topK, _ := strconv.Atoi(r.URL.Query().Get("top_k"))
+	if topK <= 0 {
+		topK = 5
+	}
+
+	result, err := similar(r.Context(), topK)
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -0,0 +1,59 @@
+package query
+
+import (
+	"context"
+	"time"
+
+	"github.com/SzymonLeja/local-memory-engine/internal/vector"
+)
+
+const memoryKind = "memory"
+
+// memoryFilter hides inactive memories and other users' memories; without a
+// userID no memories match at all. Vault chunks are never filtered by user.
+func memoryFilter(filter *vector.Filter, mode, userID string) *vector.Filter {
+	filter.MustNot = append(filter.MustNot, vector.MatchAny("memory_status", []any{"superseded", "contradicted"}))
+	if userID == "" {
+		filter.MustNot = append(filter.MustNot, vector.MatchValue("kind", memoryKind))
+	} else {
+		filter.MustNot = append(filter.MustNot, vector.Nested(vector.Filter{
+			Must:    []vector.Condition{vector.MatchValue("kind", memoryKind)},
+			MustNot: []vector.Condition{vector.MatchValue("user_id", userID)},
+		}))
+	}
+	switch mode {
+	case "exclude":
+		filter.MustNot = append(filter.MustNot, vector.MatchValue("kind", memoryKind))
+	case "only":
+		filter.Must = append(filter.Must, vector.MatchValue("kind", memoryKind))
+	}
+	return filter
+}
+
+func (s *Service) memoryResult(ctx context.Context, id string, score float64) (*ChunkResult, error) {
+	var content, conversationID, userID string
+	var importance float64
+	var createdAt time.Time
+	err := s.db.QueryRow(ctx,
+		`SELECT content, conversation_id, user_id, importance, created_at
+		 FROM memories WHERE id = $1`, id,
+	).Scan(&content, &conversationID, &userID, &importance, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ChunkResult{
+		ChunkID:    id,
+		Kind:       memoryKind,
+		ChunkText:  content,
+		Score:      score,
+		Date:       createdAt.Format(time.DateOnly),
+		DateSource: "created_at",
+		Metadata: map[string]any{
+			"conversation_id": conversationID,
+			"user_id":         userID,
+			"importance":      importance,
+			"created_at":      createdAt,
+		},
+	}, nil
+}
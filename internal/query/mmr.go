@@ -0,0 +1,86 @@
+package query
+
+import (
+	"math"
+
+	"github.com/SzymonLeja/local-memory-engine/internal/vector"
+)
+
+const (
+	candidateFactor = 4
+	maxCandidates   = 100
+)
+
+const seenPenalty = 2
+
+func diversify(hits []vector.SearchResult, topK int, lambda float64, maxPerFile int, seen map[string]bool) []vector.SearchResult {
+	selected := make([]vector.SearchResult, 0, topK)
+	perFile := make(map[string]int)
+	used := make([]bool, len(hits))
+
+	for len(selected) < topK {
+		best, bestScore := -1, math.Inf(-1)
+		for i, h := range hits {
+			if used[i] {
+				continue
+			}
+			file, _ := h.Payload["file_path"].(string)
+			if maxPerFile > 0 && file != "" && perFile[file] >= maxPerFile {
+				continue
+			}
+
+			redundancy := 0.0
+			for _, sel := range selected {
+				redundancy = max(redundancy, cosine(h.Vector, sel.Vector))
+			}
+			score := lambda*h.Score - (1-lambda)*redundancy
+			if chunkID, _ := h.Payload["chunk_id"].(string); seen[chunkID] {
+				score -= seenPenalty
+			}
+			if score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		if best < 0 {
+			break
+		}
+
+		used[best] = true
+		file, _ := hits[best].Payload["file_path"].(string)
+		perFile[file]++
+		selected = append(selected, hits[best])
+	}
+	return selected
+}
+
+func cosine(a, b []float64) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+func preferUnseen(hits []vector.SearchResult, seen map[string]bool) []vector.SearchResult {
+	if len(seen) == 0 {
+		return hits
+	}
+	out := make([]vector.SearchResult, 0, len(hits))
+	var shown []vector.SearchResult
+	for _, h := range hits {
+		if chunkID, _ := h.Payload["chunk_id"].(string); seen[chunkID] {
+			shown = append(shown, h)
+			continue
+		}
+		out = append(out, h)
+	}
+	return append(out, shown...)
+}
@@ -11,6 +11,7 @@ type Service struct {
 	db     *pgxpool.Pool
 	cfg    *config.Config
 	ollama *embeddings.OllamaClient
+	llm    *embeddings.OllamaClient
 	qdrant *vector.QdrantClient
 }
 
@@ -18,7 +19,8 @@ func NewService(
 	db *pgxpool.Pool,
 	cfg *config.Config,
 	ollama *embeddings.OllamaClient,
+	llm *embeddings.OllamaClient,
 	qdrant *vector.QdrantClient,
 ) *Service {
-	return &Service{db: db, cfg: cfg, ollama: ollama, qdrant: qdrant}
+	return &Service{db: db, cfg: cfg, ollama: ollama, llm: llm, qdrant: qdrant}
 }
@@ -0,0 +1,130 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/SzymonLeja/local-memory-engine/internal/vector"
+)
+
+type fileDate struct {
+	date   time.Time
+	source string
+
+	// created is the note's front-matter date or, without one, when the
+	// file was first seen; as_of filters on it because later edits move date
+	created time.Time
+}
+
+var noteDateLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04", time.DateOnly}
+
+func parseNoteDate(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	for _, layout := range noteDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (s *Service) fileDates(ctx context.Context, hits []vector.SearchResult) (map[string]fileDate, error) {
+	var paths []string
+	for _, h := range hits {
+		if p, _ := h.Payload["file_path"].(string); p != "" {
+			paths = append(paths, p)
+		}
+	}
+
+	rows, err := s.db.Query(ctx,
+		`SELECT path, last_modified, created_at, COALESCE(metadata->>'date', '') FROM files WHERE path = ANY($1)`,
+		paths,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query file dates: %w", err)
+	}
+	defer rows.Close()
+
+	dates := make(map[string]fileDate, len(paths))
+	for rows.Next() {
+		var path, noteDate string
+		var modified, created *time.Time
+		if err := rows.Scan(&path, &modified, &created, &noteDate); err != nil {
+			continue
+		}
+		if t, ok := parseNoteDate(noteDate); ok {
+			dates[path] = fileDate{date: t, source: "front_matter", created: t}
+		} else if modified != nil {
+			d := fileDate{date: *modified, source: "last_modified", created: *modified}
+			if created != nil && created.Before(d.created) {
+				d.created = *created
+			}
+			dates[path] = d
+		}
+	}
+	return dates, rows.Err()
+}
+
+func filterAsOf(hits []vector.SearchResult, dates map[string]fileDate, asOf time.Time) []vector.SearchResult {
+	out := hits[:0]
+	for _, h := range hits {
+		p, _ := h.Payload["file_path"].(string)
+		if d, ok := dates[p]; ok && d.created.After(asOf) {
+			continue
+		}
+		out = append(out, h)
+	}
+	return out
+}
+
+type dating struct {
+	asOf     *time.Time
+	boost    bool
+	halfLife float64
+	weight   float64
+
+	dates      map[string]fileDate
+	similarity map[string]float64
+}
+
+func (s *Service) applyDates(ctx context.Context, hits []vector.SearchResult, d *dating) ([]vector.SearchResult, error) {
+	dates, err := s.fileDates(ctx, hits)
+	if err != nil {
+		return nil, err
+	}
+	for p, fd := range dates {
+		d.dates[p] = fd
+	}
+
+	now := time.Now()
+	if d.asOf != nil {
+		hits = filterAsOf(hits, dates, *d.asOf)
+		now = *d.asOf
+	}
+	if d.boost {
+		applyRecency(hits, dates, now, d.halfLife, d.weight, d.similarity)
+	}
+	return hits, nil
+}
+
+func applyRecency(hits []vector.SearchResult, dates map[string]fileDate, now time.Time, halfLifeDays, weight float64, similarity map[string]float64) {
+	for i, h := range hits {
+		chunkID, _ := h.Payload["chunk_id"].(string)
+		similarity[chunkID] = h.Score
+
+		p, _ := h.Payload["file_path"].(string)
+		d, ok := dates[p]
+		if !ok {
+			continue
+		}
+		ageDays := max(now.Sub(d.date).Hours()/24, 0)
+		decay := math.Pow(0.5, ageDays/halfLifeDays)
+		hits[i].Score = h.Score * ((1 - weight) + weight*decay)
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+}
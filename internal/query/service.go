@@ -3,6 +3,8 @@ package query
 import (
 	"context"
 	"fmt"
+	"path"
+	"sort"
 	"time"
 
 	"github.com/SzymonLeja/local-memory-engine/internal/vector"
@@ -10,52 +12,276 @@ import (
 )
 
 type QueryResult struct {
-	QueryID  string        `json:"query_id"`
-	Results  []ChunkResult `json:"results"`
-	Duration int           `json:"duration_ms"`
+	QueryID       string        `json:"query_id"`
+	Results       []ChunkResult `json:"results"`
+	Duration      int           `json:"duration_ms"`
+	MinScore      float64       `json:"min_score"`
+	BestScore     float64       `json:"best_score"`
+	LowConfidence bool          `json:"low_confidence"`
+
+	Transformations []Transformation `json:"transformations,omitempty"`
+}
+
+type Options struct {
+	Filter        map[string]any `json:"filter"`
+	ExpandLinks   int            `json:"expand_links"`
+	ContextWindow int            `json:"context_window"`
+	MMRLambda     *float64       `json:"mmr_lambda"`
+	MaxPerFile    int            `json:"max_per_file"`
+	MinScore      *float64       `json:"min_score"`
+	Transforms    []string       `json:"transforms"`
+	Messages      []Message      `json:"messages"`
+	SessionID     string         `json:"session_id"`
+	Recency       bool           `json:"recency"`
+	HalfLifeDays  float64        `json:"half_life_days"`
+	AsOf          string         `json:"as_of"`
+	Memories      string         `json:"memories"`
+	UserID        string         `json:"user_id"`
+	Level         string         `json:"level"`
 }
 
 type ChunkResult struct {
-	ChunkText string  `json:"chunk_text"`
-	FilePath  string  `json:"file_path"`
-	Position  string  `json:"position"`
-	Score     float64 `json:"score"`
+	ChunkID   string         `json:"chunk_id"`
+	Kind      string         `json:"kind,omitempty"`
+	ChunkText string         `json:"chunk_text"`
+	FilePath  string         `json:"file_path"`
+	Position  string         `json:"position"`
+	Score     float64        `json:"score"`
+	Start     string         `json:"start,omitempty"`
+	End       string         `json:"end,omitempty"`
+	Citation  string         `json:"citation,omitempty"`
+	Metadata  map[string]any `json:"metadata,omitempty"`
+	Via       []string       `json:"via,omitempty"`
+	Context   []ContextChunk `json:"context,omitempty"`
+	Seen      bool           `json:"seen,omitempty"`
+
+	Similarity float64 `json:"similarity,omitempty"`
+	Date       string  `json:"date,omitempty"`
+	DateSource string  `json:"date_source,omitempty"`
+
+	index *int
 }
 
-func (s *Service) Query(ctx context.Context, text string, topK int) (*QueryResult, error) {
+func (s *Service) Query(ctx context.Context, text string, topK int, opts Options) (*QueryResult, error) {
 	start := time.Now()
 
-	vec, err := s.ollama.Embed(ctx, text)
+	var transformations []Transformation
+	searchText := text
+	if len(opts.Messages) > 0 {
+		condensed, err := s.condenseQuery(ctx, text, opts.Messages)
+		if err != nil {
+			fmt.Printf("query condense error: %v\n", err)
+		} else if condensed != text {
+			searchText = condensed
+			transformations = append(transformations, Transformation{Type: "condense", Text: condensed})
+		}
+	}
+
+	vec, err := s.ollama.Embed(ctx, searchText)
 	if err != nil {
 		return nil, fmt.Errorf("embed query: %w", err)
 	}
 
-	hits, err := s.qdrant.Search(ctx, vec, topK)
+	minScore := s.cfg.MinScore
+	if opts.MinScore != nil {
+		minScore = *opts.MinScore
+	}
+
+	var seen map[string]bool
+	if opts.SessionID != "" {
+		seen, err = s.seenChunks(ctx, opts.SessionID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var asOf *time.Time
+	if opts.AsOf != "" {
+		t, ok := parseNoteDate(opts.AsOf)
+		if !ok {
+			return nil, fmt.Errorf("invalid as_of %q", opts.AsOf)
+		}
+		asOf = &t
+	}
+	dated := opts.Recency || asOf != nil
+	dateOpts := &dating{
+		asOf:       asOf,
+		boost:      opts.Recency,
+		halfLife:   s.cfg.RecencyHalfLifeDays,
+		weight:     s.cfg.RecencyWeight,
+		dates:      make(map[string]fileDate),
+		similarity: make(map[string]float64),
+	}
+	if opts.HalfLifeDays > 0 {
+		dateOpts.halfLife = opts.HalfLifeDays
+	}
+
+	diverse := opts.MMRLambda != nil || opts.MaxPerFile > 0
+	limit := topK
+	if diverse || dated || len(seen) > 0 {
+		limit = min(max(topK*candidateFactor, topK), maxCandidates)
+	}
+
+	searchOpts := vector.SearchOptions{
+		Filter:         levelFilter(memoryFilter(payloadFilter(opts.Filter), opts.Memories, opts.UserID), opts.Level),
+		WithVector:     opts.MMRLambda != nil,
+		ScoreThreshold: minScore,
+	}
+
+	hits, err := s.qdrant.Search(ctx, vec, limit, searchOpts)
 	if err != nil {
 		return nil, fmt.Errorf("qdrant search: %w", err)
 	}
+	similarity := make(map[string]float64)
+	recordSimilarity(similarity, hits)
+
+	if len(opts.Transforms) > 0 {
+		variants, err := s.transformQuery(ctx, searchText, opts.Transforms)
+		if err != nil {
+			fmt.Printf("query transform error: %v\n", err)
+		}
+		transformations = append(transformations, variants...)
+
+		lists := [][]vector.SearchResult{hits}
+		for _, t := range variants {
+			tvec, err := s.ollama.Embed(ctx, t.Text)
+			if err != nil {
+				return nil, fmt.Errorf("embed %s query: %w", t.Type, err)
+			}
+			found, err := s.qdrant.Search(ctx, tvec, limit, searchOpts)
+			if err != nil {
+				return nil, fmt.Errorf("qdrant search: %w", err)
+			}
+			recordSimilarity(similarity, found)
+			lists = append(lists, found)
+		}
+		hits = fuseHits(lists, limit)
+	}
+
+	if dated {
+		hits, err = s.applyDates(ctx, hits, dateOpts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if diverse {
+		lambda := 1.0
+		if opts.MMRLambda != nil {
+			lambda = min(max(*opts.MMRLambda, 0), 1)
+		}
+		hits = diversify(hits, topK, lambda, opts.MaxPerFile, seen)
+	} else {
+		hits = preferUnseen(hits, seen)
+		hits = hits[:min(topK, len(hits))]
+	}
+
+	var via map[string][]string
+	if opts.ExpandLinks > 0 && len(hits) > 0 {
+		var linked []vector.SearchResult
+		linked, via, err = s.expandLinks(ctx, vec, hits, topK, minScore, opts)
+		if err != nil {
+			return nil, err
+		}
+		recordSimilarity(similarity, linked)
+		if dated {
+			linked, err = s.applyDates(ctx, linked, dateOpts)
+			if err != nil {
+				return nil, err
+			}
+		}
+		hits = mergeHits(hits, linked)
+	}
 
 	results, err := s.enrichResults(ctx, hits)
 	if err != nil {
 		return nil, err
 	}
+	for i := range results {
+		results[i].Via = via[results[i].ChunkID]
+		results[i].Seen = seen[results[i].ChunkID]
+		results[i].Similarity = dateOpts.similarity[results[i].ChunkID]
+		if d, ok := dateOpts.dates[results[i].FilePath]; ok {
+			results[i].Date = d.date.Format(time.DateOnly)
+			results[i].DateSource = d.source
+		}
+	}
+
+	if opts.ContextWindow > 0 {
+		results, err = s.attachContext(ctx, results, opts.ContextWindow)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var bestScore float64
+	for _, r := range results {
+		bestScore = max(bestScore, similarity[r.ChunkID])
+	}
+
+	if opts.SessionID != "" {
+		if err := s.markShown(ctx, opts.SessionID, results); err != nil {
+			fmt.Printf("session log error: %v\n", err)
+		}
+	}
 
 	duration := int(time.Since(start).Milliseconds())
 	queryID := uuid.New().String()
 
-	if err := s.logProvenance(ctx, queryID, text, hits, duration); err != nil {
+	if err := s.logProvenance(ctx, queryID, text, hits, transformations, duration); err != nil {
 		fmt.Printf("provenance log error: %v\n", err)
 	}
 
 	return &QueryResult{
-		QueryID:  queryID,
-		Results:  results,
-		Duration: duration,
+		QueryID:       queryID,
+		Results:       results,
+		Duration:      duration,
+		MinScore:      minScore,
+		BestScore:     bestScore,
+		LowConfidence: len(results) == 0 || bestScore < minScore+s.cfg.ConfidenceMargin,
+
+		Transformations: transformations,
 	}, nil
 }
 
+// recordSimilarity keeps the raw similarity of each chunk before fusion and
+// recency weighting, whose scores are not comparable with min_score
+func recordSimilarity(similarity map[string]float64, hits []vector.SearchResult) {
+	for _, h := range hits {
+		id, _ := h.Payload["chunk_id"].(string)
+		similarity[id] = max(similarity[id], h.Score)
+	}
+}
+
+func mergeHits(hits, extra []vector.SearchResult) []vector.SearchResult {
+	seen := make(map[string]bool, len(hits))
+	for _, h := range hits {
+		seen[h.ID] = true
+	}
+	for _, h := range extra {
+		if !seen[h.ID] {
+			seen[h.ID] = true
+			hits = append(hits, h)
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	return hits
+}
+
+func payloadFilter(fields map[string]any) *vector.Filter {
+	filter := &vector.Filter{}
+	for key, value := range fields {
+		if values, ok := value.([]any); ok {
+			filter.Must = append(filter.Must, vector.MatchAny(key, values))
+			continue
+		}
+		filter.Must = append(filter.Must, vector.MatchValue(key, value))
+	}
+	return filter
+}
+
 func (s *Service) enrichResults(ctx context.Context, hits []vector.SearchResult) ([]ChunkResult, error) {
-	var results []ChunkResult
+	results := []ChunkResult{}
 
 	for _, hit := range hits {
 		chunkID, _ := hit.Payload["chunk_id"].(string)
@@ -63,31 +289,56 @@ func (s *Service) enrichResults(ctx context.Context, hits []vector.SearchResult)
 			continue
 		}
 
+		switch kind, _ := hit.Payload["kind"].(string); kind {
+		case memoryKind:
+			if result, err := s.memoryResult(ctx, chunkID, hit.Score); err == nil {
+				results = append(results, *result)
+			}
+			continue
+		case summaryKind:
+			if result, err := s.summaryResult(ctx, chunkID, hit.Score); err == nil {
+				results = append(results, *result)
+			}
+			continue
+		}
+
 		var chunkText, position string
 		var filePath string
+		var metadata map[string]any
+		var index *int
 		err := s.db.QueryRow(ctx,
-			`SELECT c.chunk_text, c.position, f.path
+			`SELECT c.chunk_text, c.position, f.path, f.metadata, c.chunk_index
 			 FROM chunks c
 			 JOIN files f ON f.id = c.file_id
 			 WHERE c.id = $1`,
 			chunkID,
-		).Scan(&chunkText, &position, &filePath)
+		).Scan(&chunkText, &position, &filePath, &metadata, &index)
 		if err != nil {
 			continue
 		}
 
-		results = append(results, ChunkResult{
+		result := ChunkResult{
+			ChunkID:   chunkID,
 			ChunkText: chunkText,
 			FilePath:  filePath,
 			Position:  position,
 			Score:     hit.Score,
-		})
+			Metadata:  metadata,
+			index:     index,
+		}
+		if start, ok := hit.Payload["start"].(string); ok {
+			result.Start = start
+			result.End, _ = hit.Payload["end"].(string)
+			result.Citation = fmt.Sprintf("%s @ %s–%s", path.Base(filePath), result.Start, result.End)
+		}
+
+		results = append(results, result)
 	}
 
 	return results, nil
 }
 
-func (s *Service) logProvenance(ctx context.Context, queryID, text string, hits []vector.SearchResult, duration int) error {
+func (s *Service) logProvenance(ctx context.Context, queryID, text string, hits []vector.SearchResult, transformations []Transformation, duration int) error {
 	usedChunks := make([]map[string]any, len(hits))
 	for i, h := range hits {
 		usedChunks[i] = map[string]any{
@@ -97,9 +348,9 @@ func (s *Service) logProvenance(ctx context.Context, queryID, text string, hits
 	}
 
 	_, err := s.db.Exec(ctx,
-		`INSERT INTO provenance_log (id, query_text, used_chunks, query_duration)
-		 VALUES ($1, $2, $3, $4)`,
-		queryID, text, usedChunks, duration,
+		`INSERT INTO provenance_log (id, query_text, used_chunks, query_duration, transformations)
+		 VALUES ($1, $2, $3, $4, $5)`,
+		queryID, text, usedChunks, duration, transformations,
 	)
 	return err
 }
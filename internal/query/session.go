@@ -0,0 +1,130 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	maxHistoryMessages = 10
+	maxMessageChars    = 1000
+)
+
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+const condensePrompt = `Rewrite the user's last message in the conversation below as a standalone search query for a personal knowledge base. Resolve pronouns and references to earlier messages so the query can be understood on its own.
+Return only the query.
+
+Conversation:
+%s
+
+Standalone query:`
+
+func (s *Service) condenseQuery(ctx context.Context, text string, messages []Message) (string, error) {
+	if len(messages) == 1 && strings.TrimSpace(messages[0].Content) == strings.TrimSpace(text) {
+		return text, nil
+	}
+	if len(messages) > maxHistoryMessages {
+		messages = messages[len(messages)-maxHistoryMessages:]
+	}
+
+	var history strings.Builder
+	for _, m := range messages {
+		content := strings.TrimSpace(m.Content)
+		if r := []rune(content); len(r) > maxMessageChars {
+			content = string(r[:maxMessageChars]) + "…"
+		}
+		fmt.Fprintf(&history, "%s: %s\n", m.Role, content)
+	}
+	if last := messages[len(messages)-1]; last.Role != "user" || strings.TrimSpace(last.Content) != strings.TrimSpace(text) {
+		fmt.Fprintf(&history, "user: %s\n", text)
+	}
+
+	resp, err := s.llm.Generate(ctx, fmt.Sprintf(condensePrompt, strings.TrimSpace(history.String())))
+	if err != nil {
+		return "", fmt.Errorf("condense query: %w", err)
+	}
+	resp = strings.Trim(strings.SplitN(resp, "\n", 2)[0], ` "'`)
+	if resp == "" {
+		return text, nil
+	}
+	return resp, nil
+}
+
+func (s *Service) seenChunks(ctx context.Context, sessionID string) (map[string]bool, error) {
+	rows, err := s.db.Query(ctx,
+		`SELECT chunk_id FROM session_chunks
+		 WHERE session_id = $1 AND ($2 <= 0 OR last_shown_at > NOW() - make_interval(secs => $2))`,
+		sessionID, s.cfg.SessionTTL.Seconds(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query session chunks: %w", err)
+	}
+	defer rows.Close()
+
+	seen := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err == nil {
+			seen[id] = true
+		}
+	}
+	return seen, rows.Err()
+}
+
+func (s *Service) markShown(ctx context.Context, sessionID string, results []ChunkResult) error {
+	ids := make([]string, 0, len(results))
+	added := make(map[string]bool, len(results))
+	for _, r := range results {
+		if !added[r.ChunkID] {
+			added[r.ChunkID] = true
+			ids = append(ids, r.ChunkID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	_, err := s.db.Exec(ctx,
+		`INSERT INTO session_chunks (session_id, chunk_id)
+		 SELECT $1, unnest($2::text[])
+		 ON CONFLICT (session_id, chunk_id)
+		 DO UPDATE SET shown_count = session_chunks.shown_count + 1, last_shown_at = NOW()`,
+		sessionID, ids,
+	)
+	return err
+}
+
+// StartSessionCleanup drops session chunks not shown for SESSION_TTL; expired
+// rows are already ignored by seenChunks, this only bounds the table.
+func (s *Service) StartSessionCleanup(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(max(s.cfg.SessionTTL/24, time.Minute))
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				tag, err := s.db.Exec(ctx,
+					`DELETE FROM session_chunks WHERE last_shown_at < NOW() - make_interval(secs => $1)`,
+					s.cfg.SessionTTL.Seconds(),
+				)
+				if err != nil {
+					log.Printf("session cleanup: %v", err)
+					continue
+				}
+				if n := tag.RowsAffected(); n > 0 {
+					log.Printf("session cleanup: removed %d expired rows", n)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,139 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/SzymonLeja/local-memory-engine/internal/vector"
+)
+
+var ErrNotFound = errors.New("not found")
+
+type SimilarFile struct {
+	FilePath string  `json:"file_path"`
+	Score    float64 `json:"score"`
+	Hits     int     `json:"hits"`
+}
+
+type SimilarResult struct {
+	Files  []SimilarFile `json:"files"`
+	Chunks []ChunkResult `json:"chunks"`
+}
+
+func (s *Service) SimilarToFile(ctx context.Context, filePath string, topK int) (*SimilarResult, error) {
+	rows, err := s.db.Query(ctx,
+		`SELECT c.id FROM chunks c JOIN files f ON f.id = c.file_id WHERE f.path = $1`, filePath,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query chunks: %w", err)
+	}
+	var chunkIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err == nil {
+			chunkIDs = append(chunkIDs, id)
+		}
+	}
+	rows.Close()
+	if len(chunkIDs) == 0 {
+		return nil, ErrNotFound
+	}
+
+	points, err := s.qdrant.Retrieve(ctx, chunkIDs)
+	if err != nil {
+		return nil, fmt.Errorf("qdrant retrieve: %w", err)
+	}
+	centroid := meanVector(points)
+	if centroid == nil {
+		return nil, ErrNotFound
+	}
+
+	hits, err := s.qdrant.Search(ctx, centroid, topK*candidateFactor, vector.SearchOptions{
+		Filter: excludeFile(filePath),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("qdrant search: %w", err)
+	}
+	return s.similarResult(ctx, hits, topK)
+}
+
+func (s *Service) SimilarToChunk(ctx context.Context, chunkID string, topK int) (*SimilarResult, error) {
+	var filePath string
+	err := s.db.QueryRow(ctx,
+		`SELECT f.path FROM chunks c JOIN files f ON f.id = c.file_id WHERE c.id = $1`, chunkID,
+	).Scan(&filePath)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	hits, err := s.qdrant.Recommend(ctx, []string{chunkID}, topK*candidateFactor, vector.SearchOptions{
+		Filter: excludeFile(filePath),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("qdrant recommend: %w", err)
+	}
+	return s.similarResult(ctx, hits, topK)
+}
+
+func (s *Service) similarResult(ctx context.Context, hits []vector.SearchResult, topK int) (*SimilarResult, error) {
+	byFile := make(map[string]*SimilarFile)
+	var files []*SimilarFile
+	for _, h := range hits {
+		p, _ := h.Payload["file_path"].(string)
+		f, ok := byFile[p]
+		if !ok {
+			f = &SimilarFile{FilePath: p}
+			byFile[p] = f
+			files = append(files, f)
+		}
+		f.Score = max(f.Score, h.Score)
+		f.Hits++
+	}
+	sort.SliceStable(files, func(i, j int) bool { return files[i].Score > files[j].Score })
+
+	result := &SimilarResult{Files: []SimilarFile{}}
+	for _, f := range files[:min(topK, len(files))] {
+		result.Files = append(result.Files, *f)
+	}
+
+	chunks, err := s.enrichResults(ctx, hits[:min(topK, len(hits))])
+	if err != nil {
+		return nil, err
+	}
+	result.Chunks = chunks
+	return result, nil
+}
+
+func excludeFile(filePath string) *vector.Filter {
+	return &vector.Filter{MustNot: []vector.Condition{
+		vector.MatchValue("file_path", filePath),
+		vector.MatchValue("kind", summaryKind),
+		vector.MatchValue("kind", memoryKind),
+	}}
+}
+
+func meanVector(points []vector.SearchResult) []float64 {
+	var sum []float64
+	n := 0
+	for _, p := range points {
+		if len(p.Vector) == 0 {
+			continue
+		}
+		if sum == nil {
+			sum = make([]float64, len(p.Vector))
+		}
+		if len(p.Vector) != len(sum) {
+			continue
+		}
+		for i, v := range p.Vector {
+			sum[i] += v
+		}
+		n++
+	}
+	for i := range sum {
+		sum[i] /= float64(n)
+	}
+	return sum
+}
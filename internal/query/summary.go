@@ -0,0 +1,53 @@
+package query
+
+import (
+	"context"
+	"time"
+
+	"github.com/SzymonLeja/local-memory-engine/internal/vector"
+)
+
+const summaryKind = "summary"
+
+func levelFilter(filter *vector.Filter, level string) *vector.Filter {
+	switch level {
+	case "file", "folder":
+		filter.Must = append(filter.Must,
+			vector.MatchValue("kind", summaryKind),
+			vector.MatchValue("level", level),
+		)
+	default:
+		filter.MustNot = append(filter.MustNot, vector.MatchValue("kind", summaryKind))
+	}
+	return filter
+}
+
+func (s *Service) summaryResult(ctx context.Context, id string, score float64) (*ChunkResult, error) {
+	var level, summaryPath, summary, model string
+	var updatedAt time.Time
+	err := s.db.QueryRow(ctx,
+		`SELECT level, path, summary, model, updated_at FROM summaries WHERE id = $1`, id,
+	).Scan(&level, &summaryPath, &summary, &model, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ChunkResult{
+		ChunkID:   id,
+		Kind:      summaryKind,
+		ChunkText: summary,
+		Position:  level + " summary",
+		Score:     score,
+		Metadata: map[string]any{
+			"level":      level,
+			"model":      model,
+			"updated_at": updatedAt,
+		},
+	}
+	if level == "file" {
+		result.FilePath = summaryPath
+	} else {
+		result.Metadata["folder"] = summaryPath
+	}
+	return result, nil
+}
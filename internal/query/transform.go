@@ -0,0 +1,96 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/SzymonLeja/local-memory-engine/internal/vector"
+)
+
+const rrfK = 60
+
+type Transformation struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+var listMarker = regexp.MustCompile(`^\s*(?:[-*•]|\d+[.)])\s*`)
+
+const multiQueryPrompt = `Rewrite the search query below into %d different standalone search queries for a personal knowledge base. Cover the different things the user might mean and spell out anything implied.
+Return only the queries, one per line, without numbering or commentary.
+
+Query: %s`
+
+const hydePrompt = `Write a short passage (3-5 sentences) from a note in a personal knowledge base that answers the question below. Write it as the note itself, without mentioning the question.
+
+Question: %s`
+
+func (s *Service) transformQuery(ctx context.Context, text string, transforms []string) ([]Transformation, error) {
+	var out []Transformation
+	for _, t := range transforms {
+		switch t {
+		case "multi":
+			resp, err := s.llm.Generate(ctx, fmt.Sprintf(multiQueryPrompt, s.cfg.QueryVariants, text))
+			if err != nil {
+				return nil, fmt.Errorf("multi-query: %w", err)
+			}
+			n := 0
+			for _, line := range strings.Split(resp, "\n") {
+				line = strings.TrimSpace(listMarker.ReplaceAllString(line, ""))
+				if line == "" || strings.EqualFold(line, text) {
+					continue
+				}
+				out = append(out, Transformation{Type: "multi", Text: line})
+				if n++; n == s.cfg.QueryVariants {
+					break
+				}
+			}
+		case "hyde":
+			resp, err := s.llm.Generate(ctx, fmt.Sprintf(hydePrompt, text))
+			if err != nil {
+				return nil, fmt.Errorf("hyde: %w", err)
+			}
+			if resp != "" {
+				out = append(out, Transformation{Type: "hyde", Text: resp})
+			}
+		default:
+			return nil, fmt.Errorf("unknown transform %q", t)
+		}
+	}
+	return out, nil
+}
+
+func fuseHits(lists [][]vector.SearchResult, limit int) []vector.SearchResult {
+	type fused struct {
+		hit vector.SearchResult
+		rrf float64
+	}
+	byID := make(map[string]*fused)
+	var order []string
+
+	for _, list := range lists {
+		for rank, h := range list {
+			f, ok := byID[h.ID]
+			if !ok {
+				f = &fused{hit: h}
+				byID[h.ID] = f
+				order = append(order, h.ID)
+			}
+			f.rrf += 1 / float64(rrfK+rank+1)
+			if h.Score > f.hit.Score {
+				f.hit.Score = h.Score
+			}
+		}
+	}
+
+	sort.SliceStable(order, func(i, j int) bool { return byID[order[i]].rrf > byID[order[j]].rrf })
+
+	out := make([]vector.SearchResult, 0, min(limit, len(order)))
+	for _, id := range order[:min(limit, len(order))] {
+		out = append(out, byID[id].hit)
+	}
+	return out
+}
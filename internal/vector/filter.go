@@ -0,0 +1,45 @@
+package vector
+
+import "encoding/json"
+
+type Filter struct {
+	Must    []Condition `json:"must,omitempty"`
+	MustNot []Condition `json:"must_not,omitempty"`
+}
+
+// Condition is either a key/match pair or, with Filter set, a nested filter
+type Condition struct {
+	Key   string `json:"key,omitempty"`
+	Match *Match `json:"match,omitempty"`
+	*Filter
+}
+
+type Match struct {
+	Value any
+	Any   []any
+}
+
+// MarshalJSON sends exactly one of value or any, so false, 0 and "" are
+// still matched on
+func (m Match) MarshalJSON() ([]byte, error) {
+	if m.Any != nil {
+		return json.Marshal(map[string]any{"any": m.Any})
+	}
+	return json.Marshal(map[string]any{"value": m.Value})
+}
+
+func MatchValue(key string, value any) Condition {
+	return Condition{Key: key, Match: &Match{Value: value}}
+}
+
+func MatchAny(key string, values []any) Condition {
+	return Condition{Key: key, Match: &Match{Any: values}}
+}
+
+func Nested(filter Filter) Condition {
+	return Condition{Filter: &filter}
+}
+
+func (f *Filter) Empty() bool {
+	return f == nil || (len(f.Must) == 0 && len(f.MustNot) == 0)
+}
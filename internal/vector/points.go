@@ -56,12 +56,22 @@ type SearchResult struct {
 	ID      string         `json:"id"`
 	Score   float64        `json:"score"`
 	Payload map[string]any `json:"payload"`
+	Vector  []float64      `json:"vector,omitempty"`
+}
+
+type SearchOptions struct {
+	Filter         *Filter
+	WithVector     bool
+	ScoreThreshold float64
 }
 
 type searchRequest struct {
-	Vector      []float64 `json:"vector"`
-	Limit       int       `json:"limit"`
-	WithPayload bool      `json:"with_payload"`
+	Vector         []float64 `json:"vector"`
+	Limit          int       `json:"limit"`
+	WithPayload    bool      `json:"with_payload"`
+	WithVector     bool      `json:"with_vector,omitempty"`
+	ScoreThreshold *float64  `json:"score_threshold,omitempty"`
+	Filter         *Filter   `json:"filter,omitempty"`
 }
 
 type searchResponse struct {
@@ -69,35 +79,78 @@ type searchResponse struct {
 		ID      string         `json:"id"`
 		Score   float64        `json:"score"`
 		Payload map[string]any `json:"payload"`
+		Vector  []float64      `json:"vector"`
 	} `json:"result"`
 }
 
-func (c *QdrantClient) Search(ctx context.Context, vector []float64, limit int) ([]SearchResult, error) {
-	body, err := json.Marshal(searchRequest{
+func (c *QdrantClient) Search(ctx context.Context, vector []float64, limit int, opts SearchOptions) ([]SearchResult, error) {
+	req := searchRequest{
 		Vector:      vector,
 		Limit:       limit,
 		WithPayload: true,
-	})
+		WithVector:  opts.WithVector,
+	}
+	if !opts.Filter.Empty() {
+		req.Filter = opts.Filter
+	}
+	if opts.ScoreThreshold > 0 {
+		req.ScoreThreshold = &opts.ScoreThreshold
+	}
+	return c.searchPoints(ctx, "points/search", req)
+}
+
+type recommendRequest struct {
+	Positive       []string `json:"positive"`
+	Limit          int      `json:"limit"`
+	WithPayload    bool     `json:"with_payload"`
+	WithVector     bool     `json:"with_vector,omitempty"`
+	ScoreThreshold *float64 `json:"score_threshold,omitempty"`
+	Filter         *Filter  `json:"filter,omitempty"`
+}
+
+func (c *QdrantClient) Recommend(ctx context.Context, positive []string, limit int, opts SearchOptions) ([]SearchResult, error) {
+	ids := make([]string, len(positive))
+	for i, id := range positive {
+		ids[i] = toUUID(id)
+	}
+
+	req := recommendRequest{
+		Positive:    ids,
+		Limit:       limit,
+		WithPayload: true,
+		WithVector:  opts.WithVector,
+	}
+	if !opts.Filter.Empty() {
+		req.Filter = opts.Filter
+	}
+	if opts.ScoreThreshold > 0 {
+		req.ScoreThreshold = &opts.ScoreThreshold
+	}
+	return c.searchPoints(ctx, "points/recommend", req)
+}
+
+func (c *QdrantClient) searchPoints(ctx context.Context, endpoint string, req any) ([]SearchResult, error) {
+	body, err := json.Marshal(req)
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
-		fmt.Sprintf("%s/collections/%s/points/search", c.baseURL, c.collection),
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		fmt.Sprintf("%s/collections/%s/%s", c.baseURL, c.collection, endpoint),
 		bytes.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.client.Do(req)
+	resp, err := c.client.Do(httpReq)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("qdrant search: status %d", resp.StatusCode)
+		return nil, fmt.Errorf("qdrant %s: status %d", endpoint, resp.StatusCode)
 	}
 
 	var result searchResponse
@@ -107,7 +160,21 @@ func (c *QdrantClient) Search(ctx context.Context, vector []float64, limit int)
 
 	out := make([]SearchResult, len(result.Result))
 	for i, r := range result.Result {
-		out[i] = SearchResult{ID: r.ID, Score: r.Score, Payload: r.Payload}
+		out[i] = SearchResult{ID: r.ID, Score: r.Score, Payload: r.Payload, Vector: r.Vector}
 	}
 	return out, nil
 }
+
+type retrieveRequest struct {
+	IDs         []string `json:"ids"`
+	WithPayload bool     `json:"with_payload"`
+	WithVector  bool     `json:"with_vector"`
+}
+
+func (c *QdrantClient) Retrieve(ctx context.Context, pointIDs []string) ([]SearchResult, error) {
+	ids := make([]string, len(pointIDs))
+	for i, id := range pointIDs {
+		ids[i] = toUUID(id)
+	}
+	return c.searchPoints(ctx, "points", retrieveRequest{IDs: ids, WithPayload: true, WithVector: true})
+}
@@ -78,7 +78,7 @@ func (c *QdrantClient) EnsureCollection(ctx context.Context, vectorSize int) err
 func (c *QdrantClient) Delete(ctx context.Context, pointID string) error {
 	url := fmt.Sprintf("%s/collections/%s/points/delete", c.baseURL, c.collection)
 	body := map[string]any{
-		"points": []string{pointID},
+		"points": []string{toUUID(pointID)},
 	}
 	data, _ := json.Marshal(body)
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
@@ -91,5 +91,43 @@ func (c *QdrantClient) Delete(ctx context.Context, pointID string) error {
 		return err
 	}
 	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("qdrant delete: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (c *QdrantClient) SetPayload(ctx context.Context, pointIDs []string, payload map[string]any) error {
+	ids := make([]string, len(pointIDs))
+	for i, id := range pointIDs {
+		ids[i] = toUUID(id)
+	}
+
+	data, err := json.Marshal(map[string]any{
+		"points":  ids,
+		"payload": payload,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		fmt.Sprintf("%s/collections/%s/points/payload", c.baseURL, c.collection),
+		bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("qdrant set payload: status %d", resp.StatusCode)
+	}
 	return nil
 }
@@ -0,0 +1,7 @@
+-- +goose Up
+
+ALTER TABLE files ADD COLUMN metadata JSONB;
+
+-- +goose Down
+
+ALTER TABLE files DROP COLUMN metadata;
@@ -0,0 +1,20 @@
+-- +goose Up
+
+CREATE TABLE links (
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    source_file_id UUID REFERENCES files(id) ON DELETE CASCADE,
+    target         TEXT NOT NULL,            -- link target as written in the note
+    target_path    TEXT,                     -- vault path the target resolved to
+    alias          TEXT,
+    anchor         TEXT,
+    kind           TEXT NOT NULL,            -- wikilink/markdown
+    resolved       BOOLEAN DEFAULT FALSE,
+    created_at     TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX links_source_file_id_idx ON links (source_file_id);
+CREATE INDEX links_target_path_idx ON links (target_path);
+
+-- +goose Down
+
+DROP TABLE links;
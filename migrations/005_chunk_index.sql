@@ -0,0 +1,15 @@
+-- +goose Up
+
+ALTER TABLE chunks ADD COLUMN chunk_index INT;
+
+-- insertion order is not document order for files updated incrementally, so
+-- force a reindex instead of backfilling; unchanged chunks keep their
+-- embeddings and only get their chunk_index set
+UPDATE files SET file_hash = '';
+
+CREATE INDEX chunks_file_id_chunk_index_idx ON chunks (file_id, chunk_index);
+
+-- +goose Down
+
+DROP INDEX chunks_file_id_chunk_index_idx;
+ALTER TABLE chunks DROP COLUMN chunk_index;
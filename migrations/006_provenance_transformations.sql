@@ -0,0 +1,7 @@
+-- +goose Up
+
+ALTER TABLE provenance_log ADD COLUMN transformations JSONB;
+
+-- +goose Down
+
+ALTER TABLE provenance_log DROP COLUMN transformations;
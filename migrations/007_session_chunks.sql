@@ -0,0 +1,14 @@
+-- +goose Up
+
+CREATE TABLE session_chunks (
+    session_id     TEXT NOT NULL,
+    chunk_id       TEXT NOT NULL,
+    shown_count    INT DEFAULT 1,
+    first_shown_at TIMESTAMP DEFAULT NOW(),
+    last_shown_at  TIMESTAMP DEFAULT NOW(),
+    PRIMARY KEY (session_id, chunk_id)
+);
+
+-- +goose Down
+
+DROP TABLE session_chunks;
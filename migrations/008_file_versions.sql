@@ -0,0 +1,15 @@
+-- +goose Up
+
+CREATE TABLE file_versions (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    file_id    UUID REFERENCES files(id) ON DELETE CASCADE,
+    version    INT NOT NULL,
+    file_hash  TEXT NOT NULL,
+    content    BYTEA NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE (file_id, version)
+);
+
+-- +goose Down
+
+DROP TABLE file_versions;
@@ -0,0 +1,17 @@
+-- +goose Up
+
+CREATE TABLE memories (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    content         TEXT NOT NULL,
+    conversation_id TEXT NOT NULL DEFAULT '',
+    user_id         TEXT NOT NULL DEFAULT '',
+    importance      REAL NOT NULL DEFAULT 0.5,
+    created_at      TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX memories_user_idx ON memories (user_id, created_at);
+CREATE INDEX memories_conversation_idx ON memories (conversation_id);
+
+-- +goose Down
+
+DROP TABLE memories;
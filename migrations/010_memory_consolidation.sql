@@ -0,0 +1,27 @@
+-- +goose Up
+
+ALTER TABLE memories
+    ADD COLUMN status        TEXT NOT NULL DEFAULT 'active',  -- active/superseded/contradicted
+    ADD COLUMN superseded_by UUID REFERENCES memories(id) ON DELETE SET NULL;
+
+CREATE INDEX memories_superseded_by_idx ON memories (superseded_by);
+
+CREATE TABLE memory_proposals (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    job_id       UUID REFERENCES jobs(id) ON DELETE SET NULL,
+    memory_ids   UUID[] NOT NULL,
+    contradicted UUID[] NOT NULL DEFAULT '{}',
+    content      TEXT NOT NULL,
+    similarity   REAL,
+    status       TEXT NOT NULL DEFAULT 'pending',  -- pending/applied/rejected
+    memory_id    UUID REFERENCES memories(id) ON DELETE SET NULL,
+    created_at   TIMESTAMP DEFAULT NOW(),
+    decided_at   TIMESTAMP
+);
+
+CREATE INDEX memory_proposals_status_idx ON memory_proposals (status);
+
+-- +goose Down
+
+DROP TABLE memory_proposals;
+ALTER TABLE memories DROP COLUMN superseded_by, DROP COLUMN status;
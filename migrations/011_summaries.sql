@@ -0,0 +1,20 @@
+-- +goose Up
+
+CREATE TABLE summaries (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    level       TEXT NOT NULL,  -- file/folder
+    path        TEXT NOT NULL,
+    file_id     UUID REFERENCES files(id) ON DELETE CASCADE,
+    source_hash TEXT NOT NULL,  -- files.file_hash, or a hash of the child summaries for folders
+    summary     TEXT NOT NULL,
+    model       TEXT NOT NULL,
+    created_at  TIMESTAMP DEFAULT NOW(),
+    updated_at  TIMESTAMP DEFAULT NOW(),
+    UNIQUE (level, path)
+);
+
+CREATE INDEX summaries_file_id_idx ON summaries (file_id);
+
+-- +goose Down
+
+DROP TABLE summaries;
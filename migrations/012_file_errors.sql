@@ -0,0 +1,7 @@
+-- +goose Up
+
+ALTER TABLE files ADD COLUMN error TEXT;
+
+-- +goose Down
+
+ALTER TABLE files DROP COLUMN error;
@@ -0,0 +1,7 @@
+-- +goose Up
+
+CREATE INDEX session_chunks_last_shown_at_idx ON session_chunks (last_shown_at);
+
+-- +goose Down
+
+DROP INDEX session_chunks_last_shown_at_idx;
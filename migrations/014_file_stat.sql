@@ -0,0 +1,9 @@
+-- +goose Up
+
+ALTER TABLE files ADD COLUMN size BIGINT;
+ALTER TABLE files ADD COLUMN mtime TIMESTAMP;  -- disk mtime when file_hash was computed
+
+-- +goose Down
+
+ALTER TABLE files DROP COLUMN mtime;
+ALTER TABLE files DROP COLUMN size;
@@ -0,0 +1,23 @@
+-- +goose Up
+
+-- versions outlive their files row, so a deleted note can still be restored
+ALTER TABLE file_versions ADD COLUMN path TEXT;
+UPDATE file_versions v SET path = f.path FROM files f WHERE f.id = v.file_id;
+ALTER TABLE file_versions ALTER COLUMN path SET NOT NULL;
+
+ALTER TABLE file_versions DROP CONSTRAINT file_versions_file_id_fkey;
+ALTER TABLE file_versions ADD CONSTRAINT file_versions_file_id_fkey
+    FOREIGN KEY (file_id) REFERENCES files(id) ON DELETE SET NULL;
+
+CREATE INDEX file_versions_path_idx ON file_versions (path, version);
+
+-- +goose Down
+
+DROP INDEX file_versions_path_idx;
+DELETE FROM file_versions WHERE file_id IS NULL;
+
+ALTER TABLE file_versions DROP CONSTRAINT file_versions_file_id_fkey;
+ALTER TABLE file_versions ADD CONSTRAINT file_versions_file_id_fkey
+    FOREIGN KEY (file_id) REFERENCES files(id) ON DELETE CASCADE;
+
+ALTER TABLE file_versions DROP COLUMN path;
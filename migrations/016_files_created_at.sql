@@ -0,0 +1,7 @@
+-- +goose Up
+
+-- created_at is the first ingest; a file that already existed on disk was
+-- created no later than the mtime recorded then
+UPDATE files SET created_at = last_modified WHERE last_modified < created_at;
+
+-- +goose Down
@@ -26,9 +26,21 @@ class Filter:
             return body
 
         query = messages[-1]["content"]
+        history = [
+            {"role": m.get("role"), "content": m.get("content", "")}
+            for m in messages[-10:]
+            if m.get("role") in ("user", "assistant") and isinstance(m.get("content"), str)
+        ]
+        session_id = body.get("metadata", {}).get("chat_id") or body.get("chat_id", "")
         resp = requests.post(
             f"{self.valves.lme_url}/query",
-            json={"q": query, "topk": self.valves.topk},
+            json={
+                "q": query,
+                "top_k": self.valves.topk,
+                "messages": history,
+                "session_id": session_id,
+                "user_id": (__user__ or {}).get("id", ""),
+            },
             headers={
                 "X-API-Key": self.valves.lme_key,
                 "Content-Type": "application/json",
@@ -37,8 +49,15 @@ class Filter:
         )
 
         if resp.status_code == 200:
-            results = resp.json().get("results", [])
+            data = resp.json()
+            if data.get("low_confidence"):
+                print("💾 RAG: low confidence, skipping injection")
+                return body
+            results = data.get("results", [])
             
+            memories = [r for r in results if r.get("kind") == "memory"]
+            results = [r for r in results if r.get("kind") != "memory"]
+
             # TOP 1 = PEŁNY plik, reszta chunki
             full_context = []
             if results:
@@ -56,6 +75,9 @@ class Filter:
             chunk_context = "\n".join([
                 f"📄 **{r.get('file_path')}** [{r.get('chunk_index', 0)}]: {r.get('chunk_text', '')[:400]}"
                 for r in results[1:self.valves.topk+1]
+            ] + [
+                f"🧠 ({r.get('date', '')}): {r.get('chunk_text', '')}"
+                for r in memories
             ])
             
             context = "\n\n".join([c for c in [full_context[0] if full_context else "", chunk_context] if c])
@@ -63,9 +85,9 @@ class Filter:
             if context:
                 messages.insert(-1, {
                     "role": "system",
-                    "content": f"LME Vault ({len(results)} sources):\n{context}"
+                    "content": f"LME Vault ({len(results) + len(memories)} sources):\n{context}"
                 })
-                print(f"💾 RAG: 1 full file + {len(results)-1} chunks")
+                print(f"💾 RAG: {len(results)} chunks + {len(memories)} memories")
         
         return body
 
@@ -93,38 +115,26 @@ class Filter:
         if not (content.strip() and query.strip()):
             return body
 
-        # SMART FILENAME: pierwsze 3 słowa + .md
-        filename_words = query.split()[:3]
-        filename = "-".join(filename_words).lower() + ".md"
-        print(f"📁 Filename: '{filename}' | Query: '{query[:50]}'")
-
-        note = f"\n## Q: {query}\n\n{content}\n\n---\n"
-
-        # 1. SPRÓBUJ PATCH append (jeśli istnieje)
-        resp = requests.patch(
-            f"{self.valves.lme_url}/ingest",
-            json={"filename": filename, "append": note},  # DODAJ do końca!
+        session_id = body.get("metadata", {}).get("chat_id") or body.get("chat_id", "")
+        resp = requests.post(
+            f"{self.valves.lme_url}/memories",
+            json={
+                "messages": [
+                    {"role": "user", "content": query},
+                    {"role": "assistant", "content": content},
+                ],
+                "conversation_id": session_id,
+                "user_id": (__user__ or {}).get("id", ""),
+            },
             headers={
                 "X-API-Key": self.valves.lme_key,
                 "Content-Type": "application/json",
             },
-            timeout=10,
+            timeout=60,
         )
 
-        if resp.status_code == 404:  # Nie istnieje → POST create
-            print("📄 Create new file")
-            resp = requests.post(
-                f"{self.valves.lme_url}/ingest",
-                json={
-                    "filename": filename,
-                    "content": f"# {filename.replace('-', ' ').title()}\n\n" + note,
-                },
-                headers={
-                    "X-API-Key": self.valves.lme_key,
-                    "Content-Type": "application/json",
-                },
-                timeout=10,
-            )
-
-        print(f"💾 {resp.status_code}: {resp.json().get('job_id')}")
+        if resp.status_code == 201:
+            print(f"🧠 {resp.json().get('count', 0)} memories saved")
+        else:
+            print(f"🧠 {resp.status_code}: {resp.text[:200]}")
         return body
@@ -1,4 +1,6 @@
 #!/bin/sh
 echo "Pulling nomic-embed-text model..."
 ollama pull nomic-embed-text
-echo "Model ready."
+echo "Pulling llama3.2 model..."
+ollama pull llama3.2
+echo "Models ready."